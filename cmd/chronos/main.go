@@ -5,51 +5,159 @@ import (
 	"fmt"
 	"github.com/pdufour/Chronos/domain"
 	"github.com/pdufour/Chronos/output"
-	"github.com/pdufour/Chronos/pointerAnalysis"
 	"github.com/pdufour/Chronos/ssaUtils"
 	"github.com/pdufour/Chronos/utils"
 	"golang.org/x/tools/go/ssa"
 	"os"
+	"path/filepath"
+	"time"
 )
 
 func main() {
 	defaultFile := flag.String("file", "", "The file containing the entry point of the program")
-	defaultModulePath := flag.String("mod", "", "PPath to the module where the search should be performed. Path to module can be relative or absolute but must contain the format:{VCS}/{organization}/{package}. Packages outside this path are excluded rom the search.")
+	defaultModulePath := flag.String("mod", ".", "Path to the module of the file, from which package patterns are resolved too. The main modules are read from its go.mod, or from go.work when in a workspace. Packages outside of the main modules are excluded from the search.")
+	defaultFormat := flag.String("format", "text", "The format of the report. Can be text, sarif or json.")
+	defaultInclude := flag.String("include", "", "Comma separated package patterns to search, like github.com/org/repo/pkg/.... Other packages of the main modules are excluded from the search.")
+	defaultExclude := flag.String("exclude", "", "Comma separated package patterns to exclude from the search, like github.com/org/repo/internal/*/mocks.")
+	defaultTests := flag.Bool("tests", false, "Analyze the tests of the packages matching the patterns. Each TestXxx, BenchmarkXxx, FuzzXxx and TestMain function is analyzed on its own, except for parallel tests, which run concurrently.")
+	defaultLib := flag.Bool("lib", false, "Analyze the packages matching the patterns as libraries, by calling their exported functions and methods from two goroutines concurrently.")
+	defaultDispatch := flag.String("dispatch", "types", "The algorithm choosing the implementations called by interface method calls. Can be types, to call every runtime type implementing the interface, or the call graphs cha, rta or vta, from the least to the most precise.")
+	defaultAPI := flag.String("api", "", "Comma separated names of the functions to call in library mode, like New,Counter.*. All the exported functions are called by default.")
+	defaultCheck := flag.String("check", "race", "Comma separated checks to run. Can be race, to report data races, and deadlock, to report mutexes acquired in orders that may deadlock.")
 	flag.Parse()
-	if *defaultFile == "" {
-		fmt.Printf("Please provide a file to load\n")
+	patterns := flag.Args()
+	if *defaultFile == "" && len(patterns) == 0 {
+		fmt.Printf("Please provide a file or package patterns to load\n")
 		os.Exit(1)
 	}
-	if *defaultModulePath == "" {
-		fmt.Printf("Please provide a path to the module. path to module can be relative or absolute but must contain the format:{VCS}/{organization}/{package}.\n")
+	if (*defaultLib || *defaultTests) && *defaultFile != "" {
+		fmt.Printf("Library and tests modes require package patterns instead of a file\n")
 		os.Exit(1)
 	}
+	if *defaultLib && *defaultTests {
+		fmt.Printf("Please provide either --lib or --tests\n")
+		os.Exit(1)
+	}
+	if *defaultFormat != "text" && *defaultFormat != "sarif" && *defaultFormat != "json" {
+		fmt.Printf("Unknown format %s. Please provide text, sarif or json.\n", *defaultFormat)
+		os.Exit(1)
+	}
+	dispatch, err := ssaUtils.ParseDispatch(*defaultDispatch)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+	checks, err := ssaUtils.ParseChecks(*defaultCheck)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+	ssaUtils.GlobalDispatch = dispatch
 	domain.GoroutineCounter = utils.NewCounter()
 	domain.GuardedAccessCounter = utils.NewCounter()
 	domain.PosIDCounter = utils.NewCounter()
 
-	ssaProg, ssaPkg, err := ssaUtils.LoadPackage(*defaultFile, *defaultModulePath)
+	ssaProg, entryPoints, err := loadEntryPoints(*defaultFile, patterns, *defaultModulePath, *defaultLib, *defaultTests, *defaultAPI)
 	if err != nil {
 		fmt.Printf("Failed loading with the following error:%s\n", err)
 		os.Exit(1)
 	}
-	entryFunc := ssaPkg.Func("main")
-	err = ssaUtils.InitPreProcess(ssaProg, *defaultModulePath)
+	scope, err := loadScope(*defaultFile, patterns, *defaultModulePath, *defaultInclude, *defaultExclude)
 	if err != nil {
-		fmt.Print(err)
+		fmt.Printf("Failed reading the modules with the following error:%s\n", err)
 		os.Exit(1)
 	}
+	start := time.Now()
+	ssaUtils.InitPreProcess(ssaProg, scope)
 
-	entryCallCommon := ssa.CallCommon{Value: entryFunc}
-	functionState := ssaUtils.HandleCallCommon(domain.NewEmptyContext(), &entryCallCommon, entryFunc.Pos())
-	conflictingGAs, err := pointerAnalysis.Analysis(ssaPkg, functionState.GuardedAccesses)
-	if err != nil {
-		fmt.Printf("Error in analysis:%s\n", err)
-		os.Exit(1)
+	// Packages of the same program share the positions, so races and deadlocks reached from several entry points are
+	// reported once
+	findings := ssaUtils.CheckEntryPoints(entryPoints, checks)
+	conflictingGAs, deadlocks := findings.Conflicts, findings.Deadlocks
+	summary := output.Summary{Duration: time.Since(start), FunctionsVisited: ssaUtils.VisitedFunctions}
+	switch *defaultFormat {
+	case "sarif":
+		err = output.GenerateSarif(os.Stdout, conflictingGAs, deadlocks, ssaProg, *defaultModulePath)
+	case "json":
+		err = output.GenerateJSON(os.Stdout, conflictingGAs, deadlocks, ssaProg, summary)
+	default:
+		err = generateText(conflictingGAs, deadlocks, ssaProg, checks)
 	}
-	err = output.GenerateError(conflictingGAs, ssaProg)
 	if err != nil {
 		fmt.Printf("Error in generating errors:%s\n", err)
 		os.Exit(1)
 	}
 }
+
+// generateText prints the reports of the checks that ran.
+func generateText(conflictingGAs, deadlocks [][]*domain.GuardedAccess, ssaProg *ssa.Program, checks []ssaUtils.Check) error {
+	for _, check := range checks {
+		var err error
+		switch check {
+		case ssaUtils.CheckRace:
+			err = output.GenerateError(conflictingGAs, ssaProg)
+		case ssaUtils.CheckDeadlock:
+			err = output.GenerateDeadlockError(deadlocks, ssaProg)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadEntryPoints loads the main functions of the packages, or their test functions in tests mode.
+func loadEntryPoints(file string, patterns []string, modulePath string, isLib, isTests bool, api string) (*ssa.Program, []ssaUtils.EntryPoint, error) {
+	if isTests {
+		return ssaUtils.LoadTests(patterns, modulePath)
+	}
+	ssaProg, ssaPkgs, err := loadPackages(file, patterns, modulePath, isLib, api)
+	if err != nil {
+		return nil, nil, err
+	}
+	entryPoints := make([]ssaUtils.EntryPoint, 0, len(ssaPkgs))
+	for _, ssaPkg := range ssaPkgs {
+		entryPoints = append(entryPoints, ssaUtils.EntryPoint{Main: ssaPkg, Func: ssaPkg.Func("main")})
+	}
+	return ssaProg, entryPoints, nil
+}
+
+// loadPackages loads the main package of the file if given, or otherwise the main packages matching the patterns. In
+// library mode, the entry points synthesized for the packages matching the patterns are loaded instead.
+func loadPackages(file string, patterns []string, modulePath string, isLib bool, api string) (*ssa.Program, []*ssa.Package, error) {
+	if isLib {
+		return ssaUtils.LoadLibraries(patterns, modulePath, ssaUtils.SplitPatterns(api))
+	}
+	if file != "" {
+		file, err := filepath.Abs(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		ssaProg, ssaPkg, err := ssaUtils.LoadPackage(file, modulePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		return ssaProg, []*ssa.Package{ssaPkg}, nil
+	}
+	return ssaUtils.LoadPackages(patterns, modulePath)
+}
+
+// loadScope reads the main modules of the file if given, or otherwise of the packages matching the patterns.
+func loadScope(file string, patterns []string, modulePath, include, exclude string) (*ssaUtils.Scope, error) {
+	var modules []string
+	var err error
+	if file != "" {
+		// The file is relative to the working directory, while go list resolves it from the module
+		file, err = filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+		modules, err = ssaUtils.GetMainModules(modulePath, "file="+file)
+	} else {
+		modules, err = ssaUtils.GetMainModules(modulePath, patterns...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ssaUtils.NewScope(modules, ssaUtils.SplitPatterns(include), ssaUtils.SplitPatterns(exclude))
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func Test_loadScope_File(t *testing.T) {
+	_, ex, _, ok := runtime.Caller(0)
+	require.True(t, ok)
+	modulePath := filepath.Dir(filepath.Dir(filepath.Dir(ex)))
+
+	file := filepath.Join(modulePath, "tests", "testdata", "stdlib", "TestRaceRange", "prog1.go")
+	scope, err := loadScope(file, nil, modulePath, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"github.com/pdufour/Chronos"}, scope.Modules)
+
+	// Relative files are relative to the working directory, which is the directory of the test
+	relativeFile, err := filepath.Rel(filepath.Dir(ex), file)
+	require.NoError(t, err)
+	scope, err = loadScope(relativeFile, nil, modulePath, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"github.com/pdufour/Chronos"}, scope.Modules)
+}
+
+func Test_loadEntryPoints_PatternsFromModule(t *testing.T) {
+	_, ex, _, ok := runtime.Caller(0)
+	require.True(t, ok)
+	modulePath := filepath.Dir(filepath.Dir(filepath.Dir(ex)))
+
+	// The pattern is resolved from the module, and not from the working directory of the test
+	patterns := []string{"./tests/testdata/stdlib/TestRaceRange"}
+	_, entryPoints, err := loadEntryPoints("", patterns, modulePath, false, false, "")
+	require.NoError(t, err)
+	require.Len(t, entryPoints, 1)
+	assert.Equal(t, "main", entryPoints[0].Func.Name())
+
+	scope, err := loadScope("", patterns, modulePath, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"github.com/pdufour/Chronos"}, scope.Modules)
+}
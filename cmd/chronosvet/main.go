@@ -0,0 +1,11 @@
+// Chronosvet runs the race analyzer as a standalone tool, or as a vet tool using go vet -vettool=$(which chronosvet).
+package main
+
+import (
+	"github.com/pdufour/Chronos/passes/race"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(race.Analyzer)
+}
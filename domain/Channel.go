@@ -0,0 +1,73 @@
+package domain
+
+// Channel records the operations performed on a channel allocation site. Operations are paired by their order, following
+// the go memory model:
+//   - The k-th send happens-before the completion of the k-th receive.
+//   - A close happens-before a receive that returns because the channel is closed.
+//   - The k-th receive happens-before the completion of the k+C-th send, where C is the capacity of the channel. For
+//     unbuffered channels it means the receive happens-before the completion of its matching send.
+type Channel struct {
+	Capacity int // -1 if unknown
+	sends    []*SyncOp
+	receives []*SyncOp
+	closes   []*SyncOp
+}
+
+func NewChannel(capacity int) *Channel {
+	return &Channel{
+		Capacity: capacity,
+		sends:    make([]*SyncOp, 0),
+		receives: make([]*SyncOp, 0),
+		closes:   make([]*SyncOp, 0),
+	}
+}
+
+func (ch *Channel) AddOperation(kind SyncOpKind, event *SyncEvent) *SyncOp {
+	op := &SyncOp{Object: ch, Kind: kind, Event: event}
+	switch kind {
+	case SyncOpSend:
+		op.Index = len(ch.sends)
+		ch.sends = append(ch.sends, op)
+	case SyncOpReceive:
+		op.Index = len(ch.receives)
+		ch.receives = append(ch.receives, op)
+	case SyncOpClose:
+		op.Index = len(ch.closes)
+		ch.closes = append(ch.closes, op)
+	}
+	return op
+}
+
+func (ch *Channel) Releases(op *SyncOp) []*SyncEvent {
+	releases := make([]*SyncEvent, 0)
+	switch op.Kind {
+	case SyncOpReceive:
+		if op.Index < len(ch.sends) {
+			releases = append(releases, ch.sends[op.Index].Event)
+			break
+		}
+		for _, closeOp := range ch.closes {
+			releases = append(releases, closeOp.Event)
+		}
+	case SyncOpSend:
+		if ch.Capacity < 0 {
+			break
+		}
+		receiveIndex := op.Index - ch.Capacity
+		if receiveIndex >= 0 && receiveIndex < len(ch.receives) {
+			releases = append(releases, ch.receives[receiveIndex].Event)
+		}
+	}
+	return releases
+}
+
+func (ch *Channel) CanComplete(op *SyncOp) bool {
+	switch op.Kind {
+	case SyncOpReceive:
+		return len(ch.sends) > 0 || len(ch.closes) > 0
+	case SyncOpSend:
+		return ch.Capacity != 0 || len(ch.receives) > 0
+	default:
+		return true
+	}
+}
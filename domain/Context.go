@@ -2,6 +2,7 @@ package domain
 
 import (
 	"github.com/pdufour/Chronos/utils/stacks"
+	"golang.org/x/tools/go/ssa"
 )
 
 // Flow context
@@ -9,6 +10,15 @@ type Context struct {
 	GoroutineID int
 	Clock       VectorClock
 	StackTrace  *stacks.IntStackWithMap
+	LastAcquire *SyncEvent     // The latest event in which the flow synchronized with other goroutines.
+	Iteration   *LoopIteration // Shared by the instances of the goroutine, if it was started in a loop.
+	Test        *Test          // The test the flow runs, if it runs the function of a test.
+}
+
+// LoopIteration is shared by the instances of a goroutine started in a loop. The instances were started by different
+// iterations, so the parameters bound to the variables of the loop, like its index, have different values in each.
+type LoopIteration struct {
+	Params map[*ssa.Parameter]struct{}
 }
 
 func NewEmptyContext() *Context {
@@ -25,6 +35,44 @@ func NewGoroutineExecutionState(state *Context) *Context {
 		Clock:       state.Clock.Copy(),
 		GoroutineID: GoroutineCounter.GetNext(),
 		StackTrace:  state.StackTrace.Copy(),
+		LastAcquire: state.LastAcquire,
+	}
+}
+
+// NewLoopGoroutineExecutionState creates the flow of a goroutine started by another iteration of a loop, given the
+// state of the caller at the end of an iteration. The flow inherits the clock of the caller at the entry of the loop, so
+// it runs concurrently with the accesses of all the iterations. It's still ordered after what the caller synchronized
+// with during the iteration, like the goroutine started by the iteration when the caller waits for it.
+func NewLoopGoroutineExecutionState(state, loopEntry *Context) *Context {
+	return &Context{
+		Clock:       loopEntry.Clock.Copy(),
+		GoroutineID: GoroutineCounter.GetNext(),
+		StackTrace:  state.StackTrace.Copy(),
+		LastAcquire: state.LastAcquire,
+	}
+}
+
+// IsOtherIteration returns whether the flows are different instances of a goroutine started in a loop, and the value
+// is the same parameter bound to a variable of the loop in both, so it has a different value in each.
+func (gs *Context) IsOtherIteration(state *Context, valueA, valueB ssa.Value) bool {
+	if gs.Iteration == nil || gs.Iteration != state.Iteration || gs.GoroutineID == state.GoroutineID {
+		return false
+	}
+	param, ok := valueA.(*ssa.Parameter)
+	if !ok || valueA != valueB {
+		return false
+	}
+	_, ok = gs.Iteration.Params[param]
+	return ok
+}
+
+// NewOnceExecutionState creates the flow of a once function. The flow doesn't inherit the clock of the caller, since the
+// function runs in the flow of whichever call to Do comes first. Its ordering is acquired through the once instead.
+func NewOnceExecutionState(state *Context) *Context {
+	return &Context{
+		Clock:       VectorClock{},
+		GoroutineID: GoroutineCounter.GetNext(),
+		StackTrace:  state.StackTrace.Copy(),
 	}
 }
 
@@ -32,11 +80,28 @@ func (gs *Context) Increment() {
 	gs.Clock[gs.GoroutineID] += 1
 }
 
+// Acquire marks the event as the latest point in which the flow synchronized with other goroutines.
+func (gs *Context) Acquire(event *SyncEvent) {
+	gs.LastAcquire = event
+}
+
+// ResolvedClock returns the clock of the flow joined with the clocks it acquired from other goroutines.
+func (gs *Context) ResolvedClock() VectorClock {
+	if gs.LastAcquire == nil {
+		return gs.Clock
+	}
+	clock := gs.Clock.Copy()
+	clock.MergeClocks(gs.LastAcquire.AcquiredClock())
+	return clock
+}
+
 func (gs *Context) MayConcurrent(state *Context) bool {
-	timestampAidA := gs.Clock.Get(gs.GoroutineID)
-	timestampAidB := state.Clock.Get(gs.GoroutineID)
-	timestampBidA := gs.Clock.Get(state.GoroutineID)
-	timestampBidB := state.Clock.Get(state.GoroutineID)
+	clockA := gs.ResolvedClock()
+	clockB := state.ResolvedClock()
+	timestampAidA := clockA.Get(gs.GoroutineID)
+	timestampAidB := clockB.Get(gs.GoroutineID)
+	timestampBidA := clockA.Get(state.GoroutineID)
+	timestampBidB := clockB.Get(state.GoroutineID)
 	isBefore := timestampAidA <= timestampAidB && timestampBidA < timestampBidB
 	isAfter := timestampBidB <= timestampBidA && timestampAidB < timestampAidA
 	return !(isBefore || isAfter)
@@ -47,6 +112,9 @@ func (gs *Context) Copy() *Context {
 		GoroutineID: gs.GoroutineID,
 		Clock:       gs.Clock.Copy(),
 		StackTrace:  gs.StackTrace.Copy(),
+		LastAcquire: gs.LastAcquire,
+		Iteration:   gs.Iteration,
+		Test:        gs.Test,
 	}
 }
 
@@ -55,5 +123,8 @@ func (gs *Context) CopyWithoutMap() *Context {
 		GoroutineID: gs.GoroutineID,
 		Clock:       gs.Clock.Copy(),
 		StackTrace:  stacks.NewIntStackWithMap(*gs.StackTrace.GetItems().Copy(), nil),
+		LastAcquire: gs.LastAcquire,
+		Iteration:   gs.Iteration,
+		Test:        gs.Test,
 	}
 }
@@ -35,22 +35,23 @@ func (fs *FunctionState) AddContextToFunction(context *Context) {
 		ga.State.GoroutineID = context.GoroutineID
 		context.Increment()
 
-		relativePos := ga.State.StackTrace.Iter()[ga.PosToRemove+1:]
+		relativePos := ga.State.StackTrace.Iter()[ga.PosToRemove:]
 		tmpContext := context.CopyWithoutMap()
 		tmpContext.StackTrace.GetItems().MergeStacks((*stacks.IntStack)(&relativePos))
 		ga.State.StackTrace = tmpContext.StackTrace
 		ga.State.Clock = tmpContext.Clock
+		ga.State.LastAcquire = tmpContext.LastAcquire
 	}
 }
 
 // RemoveContextFromFunction strips any context related data from the guarded access fields. It nullifies id, goroutine id,
 // clock and removes from the guarded access the prefix that matches the context path. This way, other flows can take
-// the guarded access and add relevant data.
-func (fs *FunctionState) RemoveContextFromFunction() {
+// the guarded access and add relevant data. The context is the one the function was called in.
+func (fs *FunctionState) RemoveContextFromFunction(context *Context) {
 	gas := make([]*GuardedAccess, 0, len(fs.GuardedAccesses))
 	for i := range fs.GuardedAccesses {
 		ga := fs.GuardedAccesses[i].ShallowCopy()
-		ga.PosToRemove++
+		ga.PosToRemove = len(context.StackTrace.Iter())
 		gas = append(gas, ga)
 	}
 	fs.GuardedAccesses = gas
@@ -11,6 +11,9 @@ type OpKind int
 const (
 	GuardAccessRead OpKind = iota
 	GuardAccessWrite
+	GuardAccessAtomicRead
+	GuardAccessAtomicWrite
+	GuardAccessLock // The acquisition of a mutex, recorded for the lock order instead of the races
 )
 
 func (op OpKind) String() string {
@@ -19,11 +22,25 @@ func (op OpKind) String() string {
 		return "Read"
 	case GuardAccessWrite:
 		return "Write"
+	case GuardAccessAtomicRead:
+		return "AtomicRead"
+	case GuardAccessAtomicWrite:
+		return "AtomicWrite"
+	case GuardAccessLock:
+		return "Lock"
 	default:
 		return "Unknown op type"
 	}
 }
 
+func (op OpKind) IsWrite() bool {
+	return op == GuardAccessWrite || op == GuardAccessAtomicWrite
+}
+
+func (op OpKind) IsAtomic() bool {
+	return op == GuardAccessAtomicRead || op == GuardAccessAtomicWrite
+}
+
 type GuardedAccess struct {
 	*PosData
 	*FlowData
@@ -34,13 +51,28 @@ type PosData struct {
 	Pos    token.Pos
 	OpKind OpKind
 	Value  ssa.Value
+	Index  ssa.Value // The index of the element accessed, if the access is to an element of an array or a slice
+	Path   *AccessPath
+}
+
+// AccessPath is the memory an access reads or writes: a path of fields and elements, like ".a.b" or ".a[*]", inside the
+// memory the base pointer points to.
+type AccessPath struct {
+	Base ssa.Value
+	Path string
+}
+
+// Element returns the path of the elements of the array or the slice at the path.
+func (ap *AccessPath) Element() *AccessPath {
+	return &AccessPath{Base: ap.Base, Path: ap.Path + "[*]"}
 }
 
 type FlowData struct {
-	PosToRemove int
+	PosToRemove int // The length of the stack trace of the call the access was summarized in
 	ID          int // ID depends on the flow, which means it's unique.
 	State       *Context
 	Lockset     *Lockset
+	Mutex       LockID // The mutex acquired, if the access is the acquisition of a mutex
 }
 
 func (ga *FlowData) Copy() *FlowData {
@@ -49,6 +81,7 @@ func (ga *FlowData) Copy() *FlowData {
 		PosToRemove: ga.PosToRemove,
 		Lockset:     ga.Lockset.Copy(),
 		State:       ga.State.CopyWithoutMap(),
+		Mutex:       ga.Mutex,
 	}
 }
 
@@ -70,22 +103,21 @@ func (ga *GuardedAccess) Intersects(gaToCompare *GuardedAccess) bool {
 	if ga.ID == gaToCompare.ID || ga.State.GoroutineID == gaToCompare.State.GoroutineID {
 		return true
 	}
-	if ga.OpKind == GuardAccessRead && gaToCompare.OpKind == GuardAccessRead {
+	if !ga.OpKind.IsWrite() && !gaToCompare.OpKind.IsWrite() {
 		return true
 	}
-
-	if ssaPureUtils.FilterStructs(ga.Value, gaToCompare.Value) {
+	// Atomic operations are synchronized with each other
+	if ga.OpKind.IsAtomic() && gaToCompare.OpKind.IsAtomic() {
 		return true
 	}
 
-	for lockA := range ga.Lockset.Locks {
-		for lockB := range gaToCompare.Lockset.Locks {
-			if lockA == lockB {
-				return true
-			}
-		}
-	}
-	return false
+	return ga.Lockset.IsProtecting(gaToCompare.Lockset)
+}
+
+// IsMixedAtomic reports whether only one of the accesses is atomic. Mixing atomic and non-atomic accesses to the same
+// location is a race even if the non-atomic access is a read.
+func (ga *GuardedAccess) IsMixedAtomic(gaToCompare *GuardedAccess) bool {
+	return ga.OpKind.IsAtomic() != gaToCompare.OpKind.IsAtomic()
 }
 
 func (ga *GuardedAccess) IsConflicting(gaToCompare *GuardedAccess) bool {
@@ -94,12 +126,19 @@ func (ga *GuardedAccess) IsConflicting(gaToCompare *GuardedAccess) bool {
 
 func AddGuardedAccess(pos token.Pos, value ssa.Value, kind OpKind, lockset *Lockset, context *Context) *GuardedAccess {
 	context.Increment()
+	var index ssa.Value
+	if element, ok := value.(*ssa.IndexAddr); ok {
+		index = element.Index
+	}
+	base, path := ssaPureUtils.GetAccessPath(value)
 	return &GuardedAccess{
 		PosData: &PosData{
 			PosID:  PosIDCounter.GetNext(),
 			Pos:    pos,
 			OpKind: kind,
 			Value:  value,
+			Index:  index,
+			Path:   &AccessPath{Base: base, Path: path},
 		},
 		FlowData: &FlowData{
 			ID:      GuardedAccessCounter.GetNext(),
@@ -108,3 +147,11 @@ func AddGuardedAccess(pos token.Pos, value ssa.Value, kind OpKind, lockset *Lock
 		},
 	}
 }
+
+// AddLockAcquisition records the acquisition of the mutex. The lockset of the acquisition holds the mutexes held while
+// acquiring it, so they're acquired before the mutex in the lock order.
+func AddLockAcquisition(pos token.Pos, mutex ssa.Value, lockID LockID, lockset *Lockset, context *Context) *GuardedAccess {
+	guardedAccess := AddGuardedAccess(pos, mutex, GuardAccessLock, lockset, context)
+	guardedAccess.Mutex = lockID
+	return guardedAccess
+}
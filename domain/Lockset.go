@@ -1,12 +1,24 @@
 package domain
 
 import (
-	"go/token"
-
 	"golang.org/x/tools/go/ssa"
 )
 
-type locksLastUse map[token.Pos]*ssa.CallCommon
+// LockID identifies a held mutex by the site the mutex was allocated in, and the path to the mutex inside the
+// allocation. Read locks of an RWMutex are tracked separately from write locks, since read locks don't exclude each
+// other.
+type LockID struct {
+	Site   ssa.Value
+	Path   string
+	IsRead bool
+}
+
+// IsSameMutex reports whether both IDs refer to the same mutex, regardless of the lock mode.
+func (id LockID) IsSameMutex(lockID LockID) bool {
+	return id.Site == lockID.Site && id.Path == lockID.Path
+}
+
+type locksLastUse map[LockID]*ssa.CallCommon
 
 type Lockset struct {
 	Locks   locksLastUse
@@ -77,6 +89,19 @@ func (ls *Lockset) MergeSiblingLockset(locksetToMerge *Lockset) {
 	}
 }
 
+// IsProtecting reports whether a mutex is held by both locksets and excludes them from running together. It requires
+// the mutex to be write locked by at least one of the locksets.
+func (ls *Lockset) IsProtecting(lockset *Lockset) bool {
+	for lockA := range ls.Locks {
+		for lockB := range lockset.Locks {
+			if lockA.IsSameMutex(lockB) && (!lockA.IsRead || !lockB.IsRead) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (ls *Lockset) Copy() *Lockset {
 	newLs := &Lockset{}
 	newLocks := make(locksLastUse, len(ls.Locks))
@@ -93,8 +118,8 @@ func (ls *Lockset) Copy() *Lockset {
 }
 
 func (ls *locksLastUse) intersect(newLS locksLastUse) {
-	found := false
 	for a := range *ls {
+		found := false
 		for b := range newLS {
 			if a == b {
 				found = true
@@ -0,0 +1,66 @@
+package domain
+
+// Once records the calls to Do on a sync.Once allocation. The once function runs in the flow of whichever call comes
+// first, which is unknown statically. Therefore, the function is analyzed a single time as a flow of its own:
+//   - The flow starts after what happens-before all the calls to Do.
+//   - The completion of the flow happens-before the return of every call to Do.
+type Once struct {
+	dos   []*SyncOp
+	start *SyncEvent // The first event of the once function flow.
+	end   *SyncEvent // The last event of the once function flow.
+}
+
+func NewOnce() *Once {
+	return &Once{
+		dos: make([]*SyncOp, 0),
+	}
+}
+
+// HasRun reports whether the once function was already analyzed.
+func (o *Once) HasRun() bool {
+	return o.start != nil
+}
+
+// SetRun binds the flow of the once function, given by its first and last events, to the once.
+func (o *Once) SetRun(start, end *SyncEvent) {
+	o.start = start
+	o.end = end
+	for _, do := range o.dos {
+		o.addStartOperation(do)
+	}
+}
+
+func (o *Once) AddOperation(kind SyncOpKind, event *SyncEvent) *SyncOp {
+	op := &SyncOp{Object: o, Kind: kind, Event: event}
+	if kind == SyncOpDo {
+		op.Index = len(o.dos)
+		o.dos = append(o.dos, op)
+		if o.start != nil {
+			o.addStartOperation(op)
+		}
+	}
+	return op
+}
+
+// addStartOperation makes the start of the once function flow acquire the call to Do. Since the start event has an
+// operation for each call, only the clock common to all of them is acquired.
+func (o *Once) addStartOperation(do *SyncOp) {
+	o.start.Ops = append(o.start.Ops, &SyncOp{Object: o, Kind: SyncOpOnceStart, Index: do.Index, Event: o.start})
+}
+
+func (o *Once) Releases(op *SyncOp) []*SyncEvent {
+	releases := make([]*SyncEvent, 0)
+	switch op.Kind {
+	case SyncOpDo:
+		if o.end != nil {
+			releases = append(releases, o.end)
+		}
+	case SyncOpOnceStart:
+		releases = append(releases, o.dos[op.Index].Event)
+	}
+	return releases
+}
+
+func (o *Once) CanComplete(_ *SyncOp) bool {
+	return true
+}
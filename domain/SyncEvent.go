@@ -0,0 +1,162 @@
+package domain
+
+type SyncOpKind int
+
+const (
+	SyncOpSend SyncOpKind = iota
+	SyncOpReceive
+	SyncOpClose
+	SyncOpDone
+	SyncOpWait
+	SyncOpDo
+	SyncOpOnceStart
+	SyncOpTestReturn
+	SyncOpTestResume
+	SyncOpTestEnd
+	SyncOpTestWait
+)
+
+func (op SyncOpKind) String() string {
+	switch op {
+	case SyncOpSend:
+		return "Send"
+	case SyncOpReceive:
+		return "Receive"
+	case SyncOpClose:
+		return "Close"
+	case SyncOpDone:
+		return "Done"
+	case SyncOpWait:
+		return "Wait"
+	case SyncOpDo:
+		return "Do"
+	case SyncOpOnceStart:
+		return "OnceStart"
+	case SyncOpTestReturn:
+		return "TestReturn"
+	case SyncOpTestResume:
+		return "TestResume"
+	case SyncOpTestEnd:
+		return "TestEnd"
+	case SyncOpTestWait:
+		return "TestWait"
+	default:
+		return "Unknown sync op type"
+	}
+}
+
+// SyncObject is a synchronization primitive goroutines communicate through, such as a channel or a wait group.
+// Goroutines are analyzed when they are spawned and not when they would actually run, so the operations performed on an
+// object are known only after the whole program was traversed. Therefore, the object decides which operations
+// synchronize with each other lazily, when the clocks are resolved.
+type SyncObject interface {
+	AddOperation(kind SyncOpKind, event *SyncEvent) *SyncOp
+	// Releases returns the events that happen-before the completion of the operation.
+	Releases(op *SyncOp) []*SyncEvent
+	// CanComplete reports whether the operation has a counterpart that allows it to complete.
+	CanComplete(op *SyncOp) bool
+}
+
+type SyncOp struct {
+	Object SyncObject
+	Kind   SyncOpKind
+	Index  int // The amount of operations of the same kind that were recorded on the object before this one.
+	Event  *SyncEvent
+}
+
+// SyncEvent is a point in the flow of a goroutine where it synchronizes with other goroutines. An event may contain
+// several operations, out of which only one completes. It happens for a select, or when the operand may point to
+// several objects. Events of the same flow are chained by Prev, so the clock an event publishes contains everything
+// that was acquired by the flow before it.
+type SyncEvent struct {
+	GoroutineID int
+	Clock       VectorClock
+	Prev        *SyncEvent
+	Ops         []*SyncOp
+	IsBlocking  bool // A non blocking event (a select with a default case) might complete without any of its operations.
+
+	acquired    VectorClock // Memoized clock of all the events acquired up to and including this event.
+	isResolving bool
+}
+
+func NewSyncEvent(context *Context, isBlocking bool) *SyncEvent {
+	context.Increment()
+	return &SyncEvent{
+		GoroutineID: context.GoroutineID,
+		Clock:       context.Clock.Copy(),
+		Prev:        context.LastAcquire,
+		Ops:         make([]*SyncOp, 0),
+		IsBlocking:  isBlocking,
+	}
+}
+
+func (e *SyncEvent) AddOperation(object SyncObject, kind SyncOpKind) {
+	e.Ops = append(e.Ops, object.AddOperation(kind, e))
+}
+
+// Released returns the clock the event publishes to the operations that synchronize with it.
+func (e *SyncEvent) Released() VectorClock {
+	clock := e.Clock.Copy()
+	clock.MergeClocks(e.Prev.AcquiredClock())
+	return clock
+}
+
+// HappensBefore returns whether the event happens-before the other event, by the order of the flows and the clocks the
+// flow of the other event acquired before it.
+func (e *SyncEvent) HappensBefore(other *SyncEvent) bool {
+	if e == other {
+		return false
+	}
+	return e.Clock.Get(e.GoroutineID) <= other.Released().Get(e.GoroutineID)
+}
+
+// AcquiredClock returns the clocks acquired from other goroutines by the flow up to and including this event.
+// Computed clocks are memoized, so it must be called only after the whole program was traversed. If the pairing of
+// operations results in a cycle, the events in the cycle are resolved partially, which means less synchronization is
+// assumed.
+func (e *SyncEvent) AcquiredClock() VectorClock {
+	if e == nil {
+		return nil
+	}
+	// Walk back iteratively to the latest resolved event, to avoid a deep recursion on long flows
+	pending := make([]*SyncEvent, 0)
+	for event := e; event != nil && event.acquired == nil && !event.isResolving; event = event.Prev {
+		event.isResolving = true
+		pending = append(pending, event)
+	}
+	for i := len(pending) - 1; i >= 0; i-- {
+		event := pending[i]
+		acquired := VectorClock{}
+		if event.Prev != nil {
+			acquired.MergeClocks(event.Prev.acquired)
+		}
+		acquired.MergeClocks(event.acquire())
+		event.acquired = acquired
+		event.isResolving = false
+	}
+	return e.acquired
+}
+
+// acquire returns the clock acquired by the event itself. Since it's unknown which of the operations completes, only
+// the clock common to all of them is acquired.
+func (e *SyncEvent) acquire() VectorClock {
+	if !e.IsBlocking {
+		return nil
+	}
+	var acquired VectorClock
+	for _, op := range e.Ops {
+		if !op.Object.CanComplete(op) {
+			continue
+		}
+		opClock := VectorClock{}
+		for _, release := range op.Object.Releases(op) {
+			opClock.MergeClocks(release.Released())
+		}
+		if acquired == nil {
+			acquired = opClock
+		} else {
+			acquired.IntersectClocks(opClock)
+		}
+	}
+	return acquired
+}
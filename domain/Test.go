@@ -0,0 +1,55 @@
+package domain
+
+// Test records the run of a test function, to order its parallel subtests. t.Run returns to the parent test once the
+// subtest returned or called t.Parallel. The rest of a parallel subtest runs after the parent test function returned,
+// and the parent test completes only after its parallel subtests completed:
+//   - The parallel part of a subtest acquires the return of the function of its parent test.
+//   - The completion of a test acquires the completion of its parallel subtests.
+type Test struct {
+	Parent *Test
+	Paused *Context // The flow of the test when it called t.Parallel, which the parent test continues from.
+
+	returns []*SyncEvent
+	ends    []*SyncEvent // The completions of the parallel subtests.
+}
+
+func NewTest(parent *Test) *Test {
+	return &Test{
+		Parent:  parent,
+		returns: make([]*SyncEvent, 0),
+		ends:    make([]*SyncEvent, 0),
+	}
+}
+
+// IsParallel reports whether the test called t.Parallel.
+func (t *Test) IsParallel() bool {
+	return t.Paused != nil
+}
+
+func (t *Test) AddOperation(kind SyncOpKind, event *SyncEvent) *SyncOp {
+	op := &SyncOp{Object: t, Kind: kind, Event: event}
+	switch kind {
+	case SyncOpTestReturn:
+		op.Index = len(t.returns)
+		t.returns = append(t.returns, event)
+	case SyncOpTestEnd:
+		op.Index = len(t.ends)
+		t.ends = append(t.ends, event)
+	}
+	return op
+}
+
+func (t *Test) Releases(op *SyncOp) []*SyncEvent {
+	releases := make([]*SyncEvent, 0)
+	switch op.Kind {
+	case SyncOpTestResume:
+		releases = append(releases, t.returns...)
+	case SyncOpTestWait:
+		releases = append(releases, t.ends...)
+	}
+	return releases
+}
+
+func (t *Test) CanComplete(_ *SyncOp) bool {
+	return true
+}
@@ -13,6 +13,19 @@ func (vc VectorClock) MergeClocks(clockToMerge VectorClock) {
 	}
 }
 
+// IntersectClocks keeps for each goroutine the minimal timestamp between the clocks. It results in the timestamps that
+// are known by both of the clocks.
+func (vc VectorClock) IntersectClocks(clockToIntersect VectorClock) {
+	for goroutine, existingTimestamp := range vc {
+		newTimestamp := clockToIntersect.Get(goroutine)
+		if newTimestamp == 0 {
+			delete(vc, goroutine)
+			continue
+		}
+		vc[goroutine] = int(math.Min(float64(existingTimestamp), float64(newTimestamp)))
+	}
+}
+
 // Get returns clock for provided goroutine ID.
 // If no such goroutine or clock is not initialized,
 // then returns a zero value.
@@ -0,0 +1,61 @@
+package domain
+
+// WaitGroup records the operations performed on a wait group allocation. Wait returns only after the counter dropped to
+// zero, so the calls to Done on the wait group happen-before the completion of Wait. A wait group may be reused after a
+// Wait returned, so the calls to Done are split into phases:
+//   - A Done ordered after a Wait belongs to a later phase than the Wait.
+//   - A Done consumed by an earlier Wait, which is ordered before the Wait, belongs to an earlier phase.
+type WaitGroup struct {
+	dones []*SyncOp
+	waits []*SyncOp
+}
+
+func NewWaitGroup() *WaitGroup {
+	return &WaitGroup{
+		dones: make([]*SyncOp, 0),
+		waits: make([]*SyncOp, 0),
+	}
+}
+
+func (wg *WaitGroup) AddOperation(kind SyncOpKind, event *SyncEvent) *SyncOp {
+	op := &SyncOp{Object: wg, Kind: kind, Event: event}
+	switch kind {
+	case SyncOpDone:
+		op.Index = len(wg.dones)
+		wg.dones = append(wg.dones, op)
+	case SyncOpWait:
+		op.Index = len(wg.waits)
+		wg.waits = append(wg.waits, op)
+	}
+	return op
+}
+
+func (wg *WaitGroup) Releases(op *SyncOp) []*SyncEvent {
+	releases := make([]*SyncEvent, 0)
+	if op.Kind != SyncOpWait {
+		return releases
+	}
+	for _, done := range wg.dones {
+		if wg.isInPhase(done, op) {
+			releases = append(releases, done.Event)
+		}
+	}
+	return releases
+}
+
+// isInPhase returns whether the done belongs to the phase of the wait.
+func (wg *WaitGroup) isInPhase(done, wait *SyncOp) bool {
+	if wait.Event.HappensBefore(done.Event) {
+		return false
+	}
+	for _, earlierWait := range wg.waits {
+		if earlierWait.Event.HappensBefore(wait.Event) && !earlierWait.Event.HappensBefore(done.Event) {
+			return false
+		}
+	}
+	return true
+}
+
+func (wg *WaitGroup) CanComplete(_ *SyncOp) bool {
+	return true
+}
@@ -1,9 +1,16 @@
 module github.com/pdufour/Chronos
 
-go 1.15
+go 1.18
 
 require (
 	github.com/stretchr/testify v1.6.1
 	golang.org/x/tools v0.14.0
-	golang.org/x/tools/go/pointer v0.1.0-deprecated
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/mod v0.13.0 // indirect
+	golang.org/x/sys v0.13.0 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
 )
@@ -0,0 +1,163 @@
+package output
+
+import (
+	"encoding/json"
+	"github.com/pdufour/Chronos/domain"
+	"github.com/pdufour/Chronos/pointerAnalysis"
+	"github.com/pdufour/Chronos/ssaPureUtils"
+	"github.com/pdufour/Chronos/ssaUtils"
+	"go/token"
+	"golang.org/x/tools/go/ssa"
+	"io"
+	"sort"
+	"time"
+)
+
+// JSONSchemaVersion is increased whenever a field of the JSON report is removed or changes its meaning. Adding fields
+// doesn't change the version.
+const JSONSchemaVersion = 1
+
+// Summary holds the statistics of the analysis run that aren't part of the conflicts.
+type Summary struct {
+	Duration         time.Duration
+	FunctionsVisited int
+}
+
+// JSONReport is the root of the JSON report.
+type JSONReport struct {
+	SchemaVersion int            `json:"schemaVersion"`
+	Summary       JSONSummary    `json:"summary"`
+	Races         []JSONRace     `json:"races"`
+	Deadlocks     []JSONDeadlock `json:"deadlocks"`
+}
+
+type JSONSummary struct {
+	Races            int            `json:"races"`            // The amount of deduplicated conflicts.
+	RacesByKind      map[string]int `json:"racesByKind"`      // The amount of conflicts by rule ID, like data-race.
+	Deadlocks        int            `json:"deadlocks"`        // The amount of deduplicated deadlocks.
+	DurationMs       int64          `json:"durationMs"`       // The duration of the analysis in milliseconds.
+	FunctionsVisited int            `json:"functionsVisited"` // Functions traversed, including repeated visits.
+}
+
+// JSONRace is a pair of accesses that may run concurrently.
+type JSONRace struct {
+	Kind     string       `json:"kind"` // The rule ID of the warning, like data-race or mixed-atomic-access.
+	Message  string       `json:"message"`
+	Accesses []JSONAccess `json:"accesses"`
+}
+
+// JSONDeadlock is a cycle of acquisitions of mutexes that may wait for each other. Each acquisition holds the mutex
+// acquired by the previous one, and the first one holds the mutex acquired by the last one.
+type JSONDeadlock struct {
+	Kind         string       `json:"kind"` // The rule ID of the warning, which is deadlock.
+	Message      string       `json:"message"`
+	Acquisitions []JSONAccess `json:"acquisitions"` // The acquisitions, whose locks are the mutexes held while acquiring.
+}
+
+type JSONAccess struct {
+	JSONPosition
+	OpKind      string         `json:"opKind"` // Read, Write, AtomicRead, AtomicWrite or Lock.
+	Value       string         `json:"value"`  // The SSA value that was accessed.
+	Name        string         `json:"name"`   // The variable or field name of the value, or the SSA name otherwise.
+	Type        string         `json:"type"`
+	Locks       []JSONLock     `json:"locks"` // The mutexes held while accessing.
+	GoroutineID int            `json:"goroutineId"`
+	Clock       map[int]int    `json:"clock"` // The vector clock of the access, by goroutine ID.
+	Stack       []JSONPosition `json:"stack"` // The calls leading to the access, starting from the entry point.
+}
+
+type JSONLock struct {
+	Site   string        `json:"site"` // The allocation of the mutex, or the value used to access it.
+	Path   string        `json:"path"` // The path to the mutex inside the allocation, like .mu for a field.
+	IsRead bool          `json:"isRead"`
+	Pos    *JSONPosition `json:"pos,omitempty"`
+}
+
+type JSONPosition struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// GenerateJSON writes the deduplicated conflicts and deadlocks, and the summary as a JSONReport.
+func GenerateJSON(w io.Writer, conflictingGAs, deadlocks [][]*domain.GuardedAccess, prog *ssa.Program, summary Summary) error {
+	report := JSONReport{
+		SchemaVersion: JSONSchemaVersion,
+		Summary: JSONSummary{
+			RacesByKind:      make(map[string]int),
+			DurationMs:       summary.Duration.Milliseconds(),
+			FunctionsVisited: summary.FunctionsVisited,
+		},
+		Races:     make([]JSONRace, 0),
+		Deadlocks: make([]JSONDeadlock, 0),
+	}
+	for _, conflict := range pointerAnalysis.FilterDuplicates(conflictingGAs) {
+		kind := GetWarningKind(conflict[0], conflict[1])
+		report.Races = append(report.Races, JSONRace{
+			Kind:     kind.RuleID(),
+			Message:  kind.String(),
+			Accesses: []JSONAccess{getJSONAccess(conflict[0], prog), getJSONAccess(conflict[1], prog)},
+		})
+		report.Summary.RacesByKind[kind.RuleID()]++
+	}
+	report.Summary.Races = len(report.Races)
+	for _, deadlock := range pointerAnalysis.FilterDuplicateDeadlocks(deadlocks) {
+		jsonDeadlock := JSONDeadlock{Kind: Deadlock.RuleID(), Message: Deadlock.String(), Acquisitions: make([]JSONAccess, 0, len(deadlock))}
+		for _, acquisition := range deadlock {
+			jsonDeadlock.Acquisitions = append(jsonDeadlock.Acquisitions, getJSONAccess(acquisition, prog))
+		}
+		report.Deadlocks = append(report.Deadlocks, jsonDeadlock)
+	}
+	report.Summary.Deadlocks = len(report.Deadlocks)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func getJSONAccess(guardedAccess *domain.GuardedAccess, prog *ssa.Program) JSONAccess {
+	access := JSONAccess{
+		JSONPosition: getJSONPosition(prog.Fset.Position(guardedAccess.Pos)),
+		OpKind:       guardedAccess.OpKind.String(),
+		Value:        guardedAccess.Value.String(),
+		Name:         getValueName(guardedAccess.Value),
+		Type:         guardedAccess.Value.Type().String(),
+		Locks:        make([]JSONLock, 0),
+		GoroutineID:  guardedAccess.State.GoroutineID,
+		Clock:        guardedAccess.State.ResolvedClock(),
+		Stack:        make([]JSONPosition, 0),
+	}
+	for lock := range guardedAccess.Lockset.Locks {
+		jsonLock := JSONLock{Site: lock.Site.String(), Path: lock.Path, IsRead: lock.IsRead}
+		if lock.Site.Pos().IsValid() {
+			pos := getJSONPosition(prog.Fset.Position(lock.Site.Pos()))
+			jsonLock.Pos = &pos
+		}
+		access.Locks = append(access.Locks, jsonLock)
+	}
+	sort.Slice(access.Locks, func(i, j int) bool {
+		if access.Locks[i].Site != access.Locks[j].Site {
+			return access.Locks[i].Site < access.Locks[j].Site
+		}
+		return access.Locks[i].Path < access.Locks[j].Path
+	})
+	for _, position := range ssaUtils.GetStackPositions(prog, guardedAccess) {
+		access.Stack = append(access.Stack, getJSONPosition(position))
+	}
+	return access
+}
+
+func getJSONPosition(position token.Position) JSONPosition {
+	return JSONPosition{File: position.Filename, Line: position.Line, Column: position.Column}
+}
+
+func getValueName(value ssa.Value) string {
+	switch value := value.(type) {
+	case *ssa.Alloc:
+		if value.Comment != "" {
+			return value.Comment
+		}
+	case *ssa.FieldAddr:
+		return ssaPureUtils.GetUnderlyingObjectFromField(value).Name()
+	}
+	return value.Name()
+}
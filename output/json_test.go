@@ -0,0 +1,77 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/pdufour/Chronos/domain"
+	"github.com/pdufour/Chronos/pointerAnalysis"
+	"github.com/pdufour/Chronos/ssaUtils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestGenerateJSON(t *testing.T) {
+	f, pkg := ssaUtils.LoadMain(t, "./testdata/Race/prog1.go")
+	state := ssaUtils.HandleFunction(domain.NewEmptyContext(), f)
+	conflictingAccesses := pointerAnalysis.Analysis(ssaUtils.GlobalPointerAnalysis, state.GuardedAccesses)
+
+	buf := &bytes.Buffer{}
+	summary := Summary{Duration: 2 * time.Second, FunctionsVisited: ssaUtils.VisitedFunctions}
+	err := GenerateJSON(buf, conflictingAccesses, nil, pkg.Prog, summary)
+	require.NoError(t, err)
+	var report JSONReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	assert.Equal(t, JSONSchemaVersion, report.SchemaVersion)
+	assert.Equal(t, 1, report.Summary.Races)
+	assert.Equal(t, map[string]int{DataRace.RuleID(): 1}, report.Summary.RacesByKind)
+	assert.Equal(t, int64(2000), report.Summary.DurationMs)
+	assert.Greater(t, report.Summary.FunctionsVisited, 0)
+
+	require.Len(t, report.Races, 1)
+	race := report.Races[0]
+	assert.Equal(t, DataRace.RuleID(), race.Kind)
+	require.Len(t, race.Accesses, 2)
+	lines := make([]int, 0)
+	goroutines := make([]int, 0)
+	for _, access := range race.Accesses {
+		assert.Equal(t, "Write", access.OpKind)
+		assert.Equal(t, "x", access.Name)
+		assert.Equal(t, "*int", access.Type)
+		assert.Len(t, access.Locks, 0)
+		assert.NotEmpty(t, access.Clock)
+		lines = append(lines, access.Line)
+		goroutines = append(goroutines, access.GoroutineID)
+	}
+	assert.ElementsMatch(t, []int{7, 9}, lines)
+	assert.NotEqual(t, goroutines[0], goroutines[1])
+}
+
+func TestGenerateJSONDeadlock(t *testing.T) {
+	f, pkg := ssaUtils.LoadMain(t, "./testdata/Deadlock/prog1.go")
+	state := ssaUtils.HandleFunction(domain.NewEmptyContext(), f)
+	deadlocks := pointerAnalysis.FindDeadlocks(state.GuardedAccesses)
+
+	buf := &bytes.Buffer{}
+	err := GenerateJSON(buf, nil, deadlocks, pkg.Prog, Summary{})
+	require.NoError(t, err)
+	var report JSONReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	assert.Equal(t, 0, report.Summary.Races)
+	assert.Equal(t, 1, report.Summary.Deadlocks)
+
+	require.Len(t, report.Deadlocks, 1)
+	deadlock := report.Deadlocks[0]
+	assert.Equal(t, Deadlock.RuleID(), deadlock.Kind)
+	require.Len(t, deadlock.Acquisitions, 2)
+	lines := make([]int, 0)
+	for _, acquisition := range deadlock.Acquisitions {
+		assert.Equal(t, "Lock", acquisition.OpKind)
+		assert.Len(t, acquisition.Locks, 1)
+		assert.NotEmpty(t, acquisition.Stack)
+		lines = append(lines, acquisition.Line)
+	}
+	assert.ElementsMatch(t, []int{10, 16}, lines)
+	assert.NotEqual(t, deadlock.Acquisitions[0].GoroutineID, deadlock.Acquisitions[1].GoroutineID)
+}
@@ -0,0 +1,216 @@
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/pdufour/Chronos/domain"
+	"github.com/pdufour/Chronos/pointerAnalysis"
+	"github.com/pdufour/Chronos/utils"
+	"go/token"
+	"golang.org/x/tools/go/ssa"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	sarifVersion       = "2.1.0"
+	sarifSchema        = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifSrcRoot       = "%SRCROOT%"
+	sarifFingerprintID = "chronosRace/v1"
+	toolName           = "Chronos"
+	toolURI            = "https://github.com/pdufour/Chronos"
+)
+
+var warningKinds = []WarningKind{DataRace, MixedAtomicAccess, Deadlock}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                        `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	Results            []sarifResult                    `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	CodeFlows           []sarifCodeFlow   `json:"codeFlows"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Message          *sarifMessage         `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+type sarifCodeFlow struct {
+	ThreadFlows []sarifThreadFlow `json:"threadFlows"`
+}
+
+type sarifThreadFlow struct {
+	Message   sarifMessage              `json:"message"`
+	Locations []sarifThreadFlowLocation `json:"locations"`
+}
+
+type sarifThreadFlowLocation struct {
+	Location sarifLocation `json:"location"`
+}
+
+// GenerateSarif writes the conflicts and the deadlocks as a SARIF 2.1.0 log. Each deduplicated conflict is a result
+// with both accesses as its locations, and the call stack of each access as a thread flow. Deadlocks are results the
+// same way, with their acquisitions as the accesses. Paths under rootPath are written relative to it, so results can
+// be matched across checkouts.
+func GenerateSarif(w io.Writer, conflictingGAs, deadlocks [][]*domain.GuardedAccess, prog *ssa.Program, rootPath string) error {
+	rootPath, err := filepath.Abs(rootPath)
+	if err != nil {
+		return err
+	}
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           toolName,
+			InformationURI: toolURI,
+			Rules:          getSarifRules(),
+		}},
+		OriginalURIBaseIDs: map[string]sarifArtifactLocation{
+			sarifSrcRoot: {URI: "file://" + filepath.ToSlash(rootPath) + "/"},
+		},
+		Results: make([]sarifResult, 0),
+	}
+	for _, conflict := range pointerAnalysis.FilterDuplicates(conflictingGAs) {
+		kind := GetWarningKind(conflict[0], conflict[1])
+		run.Results = append(run.Results, getSarifResult(kind, conflict, prog, rootPath))
+	}
+	for _, deadlock := range pointerAnalysis.FilterDuplicateDeadlocks(deadlocks) {
+		run.Results = append(run.Results, getSarifResult(Deadlock, deadlock, prog, rootPath))
+	}
+	log := sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs:    []sarifRun{run},
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
+
+func getSarifRules() []sarifRule {
+	rules := make([]sarifRule, 0, len(warningKinds))
+	for _, kind := range warningKinds {
+		rules = append(rules, sarifRule{ID: kind.RuleID(), ShortDescription: sarifMessage{Text: kind.String()}})
+	}
+	return rules
+}
+
+func getSarifResult(kind WarningKind, accesses []*domain.GuardedAccess, prog *ssa.Program, rootPath string) sarifResult {
+	locations := make([]sarifLocation, 0, len(accesses))
+	threadFlows := make([]sarifThreadFlow, 0, len(accesses))
+	for i, guardedAccess := range accesses {
+		message := &sarifMessage{Text: fmt.Sprintf("%s%d: %s", kind.accessName(), i+1, guardedAccess.OpKind)}
+		location := getSarifLocation(prog.Fset.Position(guardedAccess.Pos), rootPath)
+		location.Message = message
+		locations = append(locations, location)
+
+		flowLocations := make([]sarifThreadFlowLocation, 0)
+		for _, position := range getCallStack(prog, guardedAccess) {
+			flowLocations = append(flowLocations, sarifThreadFlowLocation{Location: getSarifLocation(position, rootPath)})
+		}
+		flowLocations = append(flowLocations, sarifThreadFlowLocation{Location: location})
+		threadFlows = append(threadFlows, sarifThreadFlow{Message: *message, Locations: flowLocations})
+	}
+	return sarifResult{
+		RuleID:    kind.RuleID(),
+		RuleIndex: int(kind),
+		Level:     "warning",
+		Message:   sarifMessage{Text: kind.String()},
+		Locations: locations,
+		CodeFlows: []sarifCodeFlow{{ThreadFlows: threadFlows}},
+		PartialFingerprints: map[string]string{
+			sarifFingerprintID: getFingerprint(kind, accesses, prog, rootPath),
+		},
+	}
+}
+
+func getSarifLocation(position token.Position, rootPath string) sarifLocation {
+	artifactLocation := sarifArtifactLocation{URI: "file://" + filepath.ToSlash(position.Filename)}
+	if relativePath, ok := getRelativePath(position.Filename, rootPath); ok {
+		artifactLocation = sarifArtifactLocation{URI: relativePath, URIBaseID: sarifSrcRoot}
+	}
+	return sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+		ArtifactLocation: artifactLocation,
+		Region:           sarifRegion{StartLine: position.Line, StartColumn: position.Column},
+	}}
+}
+
+func getRelativePath(fileName, rootPath string) (string, bool) {
+	relativePath, err := filepath.Rel(rootPath, fileName)
+	if err != nil || strings.HasPrefix(relativePath, "..") {
+		return "", false
+	}
+	return filepath.ToSlash(relativePath), true
+}
+
+// getFingerprint identifies the conflict by the files and the source lines of the accesses, without their line
+// numbers, so the fingerprint stays the same when unrelated code moves the accesses.
+func getFingerprint(kind WarningKind, accesses []*domain.GuardedAccess, prog *ssa.Program, rootPath string) string {
+	parts := make([]string, 0, len(accesses))
+	for _, guardedAccess := range accesses {
+		position := prog.Fset.Position(guardedAccess.Pos)
+		fileName, ok := getRelativePath(position.Filename, rootPath)
+		if !ok {
+			fileName = filepath.Base(position.Filename)
+		}
+		line, err := utils.ReadLineByNumber(position.Filename, position.Line)
+		if err != nil {
+			line = ""
+		}
+		parts = append(parts, fileName+":"+guardedAccess.OpKind.String()+":"+strings.TrimSpace(line))
+	}
+	sort.Strings(parts)
+	hash := sha256.Sum256([]byte(kind.RuleID() + "\n" + strings.Join(parts, "\n")))
+	return hex.EncodeToString(hash[:])
+}
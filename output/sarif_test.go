@@ -0,0 +1,71 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/pdufour/Chronos/domain"
+	"github.com/pdufour/Chronos/pointerAnalysis"
+	"github.com/pdufour/Chronos/ssaUtils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGenerateSarif(t *testing.T) {
+	f, pkg := ssaUtils.LoadMain(t, "./testdata/Race/prog1.go")
+	state := ssaUtils.HandleFunction(domain.NewEmptyContext(), f)
+	conflictingAccesses := pointerAnalysis.Analysis(ssaUtils.GlobalPointerAnalysis, state.GuardedAccesses)
+
+	buf := &bytes.Buffer{}
+	err := GenerateSarif(buf, conflictingAccesses, nil, pkg.Prog, ".")
+	require.NoError(t, err)
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	assert.Equal(t, sarifVersion, log.Version)
+	require.Len(t, log.Runs, 1)
+	require.Len(t, log.Runs[0].Results, 1)
+	result := log.Runs[0].Results[0]
+	assert.Equal(t, DataRace.RuleID(), result.RuleID)
+	require.Len(t, result.Locations, 2)
+	lines := make([]int, 0)
+	for _, location := range result.Locations {
+		assert.Equal(t, "testdata/Race/prog1.go", location.PhysicalLocation.ArtifactLocation.URI)
+		assert.Equal(t, sarifSrcRoot, location.PhysicalLocation.ArtifactLocation.URIBaseID)
+		lines = append(lines, location.PhysicalLocation.Region.StartLine)
+	}
+	assert.ElementsMatch(t, []int{7, 9}, lines)
+	require.Len(t, result.CodeFlows, 1)
+	assert.Len(t, result.CodeFlows[0].ThreadFlows, 2)
+	assert.NotEmpty(t, result.PartialFingerprints[sarifFingerprintID])
+
+	buf.Reset()
+	err = GenerateSarif(buf, conflictingAccesses, nil, pkg.Prog, ".")
+	require.NoError(t, err)
+	var secondLog sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &secondLog))
+	assert.Equal(t, result.PartialFingerprints, secondLog.Runs[0].Results[0].PartialFingerprints)
+}
+
+func TestGenerateSarifDeadlock(t *testing.T) {
+	f, pkg := ssaUtils.LoadMain(t, "./testdata/Deadlock/prog1.go")
+	state := ssaUtils.HandleFunction(domain.NewEmptyContext(), f)
+	deadlocks := pointerAnalysis.FindDeadlocks(state.GuardedAccesses)
+
+	buf := &bytes.Buffer{}
+	err := GenerateSarif(buf, nil, deadlocks, pkg.Prog, ".")
+	require.NoError(t, err)
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	require.Len(t, log.Runs, 1)
+	require.Len(t, log.Runs[0].Results, 1)
+	result := log.Runs[0].Results[0]
+	assert.Equal(t, Deadlock.RuleID(), result.RuleID)
+	require.Len(t, result.CodeFlows, 1)
+	require.Len(t, result.CodeFlows[0].ThreadFlows, 2)
+	for _, threadFlow := range result.CodeFlows[0].ThreadFlows {
+		locations := threadFlow.Locations
+		for i := 1; i < len(locations); i++ {
+			assert.NotEqual(t, locations[i-1].Location.PhysicalLocation, locations[i].Location.PhysicalLocation)
+		}
+	}
+}
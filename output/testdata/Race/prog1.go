@@ -0,0 +1,10 @@
+package main
+
+func main() {
+	x := 0
+	_ = x
+	go func() {
+		x = 1
+	}()
+	x = 2
+}
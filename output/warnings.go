@@ -6,6 +6,7 @@ import (
 	"github.com/pdufour/Chronos/pointerAnalysis"
 	"github.com/pdufour/Chronos/ssaUtils"
 	"github.com/pdufour/Chronos/utils"
+	"go/token"
 	"golang.org/x/tools/go/ssa"
 	"strings"
 	"unicode"
@@ -15,6 +16,56 @@ const (
 	spacePrefixCount = 8
 )
 
+type WarningKind int
+
+const (
+	DataRace WarningKind = iota
+	MixedAtomicAccess
+	Deadlock
+)
+
+func (kind WarningKind) String() string {
+	switch kind {
+	case DataRace:
+		return "Potential race condition"
+	case MixedAtomicAccess:
+		return "Potential mixed atomic and non-atomic access"
+	case Deadlock:
+		return "Potential deadlock"
+	default:
+		return "Unknown warning"
+	}
+}
+
+// RuleID identifies the kind of warning in machine readable reports.
+func (kind WarningKind) RuleID() string {
+	switch kind {
+	case DataRace:
+		return "data-race"
+	case MixedAtomicAccess:
+		return "mixed-atomic-access"
+	case Deadlock:
+		return "deadlock"
+	default:
+		return "unknown"
+	}
+}
+
+// accessName names the accesses of the warning in the reports.
+func (kind WarningKind) accessName() string {
+	if kind == Deadlock {
+		return "Acquisition"
+	}
+	return "Access"
+}
+
+func GetWarningKind(guardedAccessA, guardedAccessB *domain.GuardedAccess) WarningKind {
+	if guardedAccessA.IsMixedAtomic(guardedAccessB) {
+		return MixedAtomicAccess
+	}
+	return DataRace
+}
+
 func GenerateError(conflictingGAs [][]*domain.GuardedAccess, prog *ssa.Program) error {
 	if len(conflictingGAs) == 0 {
 		print("No data races found\n")
@@ -39,7 +90,7 @@ func GenerateError(conflictingGAs [][]*domain.GuardedAccess, prog *ssa.Program)
 }
 
 func getMessage(guardedAccessA, guardedAccessB *domain.GuardedAccess, prog *ssa.Program) (string, error) {
-	message := "Potential race condition:\n"
+	message := GetWarningKind(guardedAccessA, guardedAccessB).String() + ":\n"
 	messageA, err := getMessageByLine(guardedAccessA, prog)
 	if err != nil {
 		return "", err
@@ -52,6 +103,48 @@ func getMessage(guardedAccessA, guardedAccessB *domain.GuardedAccess, prog *ssa.
 	return message, nil
 }
 
+// GenerateDeadlockError prints the deduplicated deadlocks. Each acquisition is printed with the position the mutex it
+// holds was locked at, which is the mutex acquired by the previous acquisition of the cycle.
+func GenerateDeadlockError(deadlocks [][]*domain.GuardedAccess, prog *ssa.Program) error {
+	if len(deadlocks) == 0 {
+		print("No deadlocks found\n")
+		return nil
+	}
+	messages := make([]string, 0)
+	for _, deadlock := range pointerAnalysis.FilterDuplicateDeadlocks(deadlocks) {
+		message := Deadlock.String() + ":\n"
+		for i, acquisition := range deadlock {
+			acquisitionMessage, err := getMessageByLine(acquisition, prog)
+			if err != nil {
+				return err
+			}
+			message += fmt.Sprintf(" %s%d:\n%s\n", Deadlock.accessName(), i+1, acquisitionMessage)
+			if lock := getHeldLock(acquisition, deadlock[(i+len(deadlock)-1)%len(deadlock)]); lock != nil {
+				message += fmt.Sprintf(" while holding the mutex locked at %s\n", prog.Fset.Position(lock.Pos()))
+			}
+			message += " \n"
+		}
+		messages = append(messages, message)
+	}
+	print(messages[0])
+	for _, message := range messages[1:] {
+		print("=========================\n")
+		print(message)
+	}
+	return nil
+}
+
+// getHeldLock returns the call that locked the mutex acquired by the previous acquisition, which is held by the
+// acquisition.
+func getHeldLock(acquisition, previous *domain.GuardedAccess) *ssa.CallCommon {
+	for lockID, call := range acquisition.Lockset.Locks {
+		if lockID.IsSameMutex(previous.Mutex) {
+			return call
+		}
+	}
+	return nil
+}
+
 func getMessageByLine(guardedAccessA *domain.GuardedAccess, prog *ssa.Program) (string, error) {
 	message := ""
 	posA := prog.Fset.Position(guardedAccessA.Pos)
@@ -62,11 +155,22 @@ func getMessageByLine(guardedAccessA *domain.GuardedAccess, prog *ssa.Program) (
 	removedSpaces := len(lineA) - len(trimmedA)
 	posToAddArrow := posA.Column - removedSpaces
 	message += "\n" + strings.Repeat(" ", posToAddArrow+spacePrefixCount-1) + "^" + "\n"
-	stackA := ssaUtils.GetStackTrace(prog, guardedAccessA)
 	if err != nil {
 		return "", err
 	}
-	stackA += posA.String()
-	message += stackA
+	for _, position := range getCallStack(prog, guardedAccessA) {
+		message += position.String() + " ->\n"
+	}
+	message += posA.String()
 	return message, nil
 }
+
+// getCallStack returns the positions of the calls that lead to the access. The stack of the acquisition of a mutex
+// already ends with the acquisition, which is dropped since the reports end the stack with the position of the access.
+func getCallStack(prog *ssa.Program, guardedAccess *domain.GuardedAccess) []token.Position {
+	stack := ssaUtils.GetStackPositions(prog, guardedAccess)
+	if last := len(stack) - 1; last >= 0 && stack[last] == prog.Fset.Position(guardedAccess.Pos) {
+		return stack[:last]
+	}
+	return stack
+}
@@ -0,0 +1,105 @@
+// Package race defines an Analyzer that reports potential data races in main packages, so Chronos can run in go vet,
+// gopls and golangci-lint.
+package race
+
+import (
+	"fmt"
+	"github.com/pdufour/Chronos/domain"
+	"github.com/pdufour/Chronos/output"
+	"github.com/pdufour/Chronos/pointerAnalysis"
+	"github.com/pdufour/Chronos/ssaUtils"
+	"github.com/pdufour/Chronos/utils"
+	"go/types"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ssa"
+	"sync"
+)
+
+const doc = `report potential data races
+
+The race analyzer traverses the program from the main function, and reports accesses to the same location from
+different goroutines that are not ordered by synchronization and at least one of them is a write.`
+
+var Analyzer = &analysis.Analyzer{
+	Name: "race",
+	Doc:  doc,
+	Run:  run,
+}
+
+var dispatch string
+
+func init() {
+	Analyzer.Flags.StringVar(&dispatch, "dispatch", string(ssaUtils.DispatchTypes), "the algorithm choosing the implementations called by interface method calls: types, cha, rta or vta")
+}
+
+// The analysis keeps its state in globals, so packages are analyzed one at a time.
+var analysisMutex sync.Mutex
+
+// run analyzes the package from the syntax and types of the pass, so it sees the files as the driver provides them,
+// like the unsaved files of an editor. The packages it imports have no bodies, so races are found in the code of the
+// package only.
+func run(pass *analysis.Pass) (interface{}, error) {
+	if pass.Pkg.Name() != "main" || len(pass.Files) == 0 {
+		return nil, nil
+	}
+	if _, ok := pass.Pkg.Scope().Lookup("main").(*types.Func); !ok {
+		return nil, nil
+	}
+
+	globalDispatch, err := ssaUtils.ParseDispatch(dispatch)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := ssaUtils.NewScope([]string{pass.Pkg.Path()}, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	ssaProg, ssaPkg := ssaUtils.BuildPackage(pass.Fset, pass.Pkg, pass.Files, pass.TypesInfo)
+	conflictingGAs := analyze(ssaProg, ssaPkg, scope, globalDispatch)
+	for _, conflict := range pointerAnalysis.FilterDuplicates(conflictingGAs) {
+		reportConflict(pass, conflict[0], conflict[1])
+	}
+	return nil, nil
+}
+
+func analyze(ssaProg *ssa.Program, ssaPkg *ssa.Package, scope *ssaUtils.Scope, globalDispatch ssaUtils.Dispatch) [][]*domain.GuardedAccess {
+	analysisMutex.Lock()
+	defer analysisMutex.Unlock()
+	ssaUtils.GlobalDispatch = globalDispatch
+	domain.GoroutineCounter = utils.NewCounter()
+	domain.GuardedAccessCounter = utils.NewCounter()
+	domain.PosIDCounter = utils.NewCounter()
+	ssaUtils.InitPreProcess(ssaProg, scope)
+	return ssaUtils.AnalyzeEntryPoint(ssaPkg)
+}
+
+// reportConflict reports the first access of the pair, and relates the second access to it. Accesses without a
+// position, like the ones of synthetic functions, are only described in the message.
+func reportConflict(pass *analysis.Pass, guardedAccessA, guardedAccessB *domain.GuardedAccess) {
+	if !guardedAccessA.Pos.IsValid() {
+		guardedAccessA, guardedAccessB = guardedAccessB, guardedAccessA
+	}
+	if !guardedAccessA.Pos.IsValid() {
+		return
+	}
+	kind := output.GetWarningKind(guardedAccessA, guardedAccessB)
+	diagnostic := analysis.Diagnostic{
+		Pos:      guardedAccessA.Pos,
+		Category: "race",
+		Message:  fmt.Sprintf("%s: %s conflicts with %s", kind, guardedAccessA.OpKind, getAccessDescription(pass, guardedAccessB)),
+	}
+	if guardedAccessB.Pos.IsValid() {
+		diagnostic.Related = []analysis.RelatedInformation{{
+			Pos:     guardedAccessB.Pos,
+			Message: fmt.Sprintf("conflicting %s", guardedAccessB.OpKind),
+		}}
+	}
+	pass.Report(diagnostic)
+}
+
+func getAccessDescription(pass *analysis.Pass, guardedAccess *domain.GuardedAccess) string {
+	if !guardedAccess.Pos.IsValid() {
+		return guardedAccess.OpKind.String()
+	}
+	return fmt.Sprintf("%s at %s", guardedAccess.OpKind, pass.Fset.Position(guardedAccess.Pos))
+}
@@ -0,0 +1,11 @@
+package race_test
+
+import (
+	"github.com/pdufour/Chronos/passes/race"
+	"golang.org/x/tools/go/analysis/analysistest"
+	"testing"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), race.Analyzer, "a")
+}
@@ -0,0 +1,22 @@
+package main
+
+import "sync"
+
+var (
+	mu sync.Mutex
+	x  int
+	y  int
+)
+
+func main() {
+	go func() {
+		x = 1 // want `Potential race condition: Write conflicts with Write`
+		mu.Lock()
+		y = 1
+		mu.Unlock()
+	}()
+	x = 2
+	mu.Lock()
+	y = 2
+	mu.Unlock()
+}
@@ -0,0 +1,53 @@
+package pointerAnalysis
+
+import (
+	"github.com/pdufour/Chronos/domain"
+	"github.com/pdufour/Chronos/ssaPureUtils"
+	"golang.org/x/tools/go/ssa"
+)
+
+// The path of an access is relative to the memory its base pointer points to, and the labels the base points to are
+// paths inside their objects. So the path of the access inside an object is the path of the label followed by the path
+// of the access. Two accesses overlap if they may access the same object, and the path of one of them inside it
+// contains the path of the other: a struct overlaps its fields, while different fields of a struct never overlap.
+
+// isDisjointPaths returns whether the accesses access different memory. When the objects the base of either access
+// points to aren't known, the paths are compared as if the bases were the same.
+func isDisjointPaths(result *Result, guardedAccessA, guardedAccessB *domain.GuardedAccess) bool {
+	pathA, pathB := guardedAccessA.Path, guardedAccessB.Path
+	if pathA == nil || pathB == nil {
+		return false
+	}
+	labelsA := result.getLabels(pathA.Base)
+	labelsB := result.getLabels(pathB.Base)
+	if len(labelsA) == 0 || len(labelsB) == 0 {
+		return !ssaPureUtils.IsOverlappingPaths(pathA.Path, pathB.Path)
+	}
+	for _, labelA := range labelsA {
+		for _, labelB := range labelsB {
+			if labelA.obj != labelB.obj {
+				continue
+			}
+			// Paths deeper than the limit are merged with their parent, so where they end isn't known
+			if getPathDepth(labelA.path) >= maxPathDepth || getPathDepth(labelB.path) >= maxPathDepth {
+				return false
+			}
+			if ssaPureUtils.IsOverlappingPaths(labelA.path+pathA.Path, labelB.path+pathB.Path) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (r *Result) getLabels(value ssa.Value) []label {
+	nodeID, ok := r.solver.nodeIDs[nodeKey{owner: value, path: ""}]
+	if !ok {
+		return nil
+	}
+	labels := make([]label, 0, len(r.solver.nodes[nodeID].ptsList))
+	for _, labelID := range r.solver.nodes[nodeID].ptsList {
+		labels = append(labels, r.solver.labels[labelID])
+	}
+	return labels
+}
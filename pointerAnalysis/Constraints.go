@@ -0,0 +1,279 @@
+package pointerAnalysis
+
+import (
+	"github.com/pdufour/Chronos/ssaPureUtils"
+	"go/token"
+	"go/types"
+	"golang.org/x/tools/go/ssa"
+	"strconv"
+)
+
+func tuplePath(i int) string {
+	return "#" + strconv.Itoa(i)
+}
+
+// resultPath returns the path of the i'th result of the function inside its results.
+func resultPath(sig *types.Signature, i int) string {
+	if sig.Results().Len() == 1 {
+		return ""
+	}
+	return tuplePath(i)
+}
+
+// addReachable queues the function to generate its constraints the first time it's found to be reachable. The
+// constraints are generated by the solver, so long chains of calls don't grow the stack.
+func (s *solver) addReachable(fn *ssa.Function) {
+	if _, ok := s.reachable[fn]; ok {
+		return
+	}
+	s.reachable[fn] = struct{}{}
+	s.pending = append(s.pending, fn)
+}
+
+func (s *solver) addConstraints(fn *ssa.Function) {
+	for _, block := range fn.Blocks {
+		for _, ins := range block.Instrs {
+			s.addInstruction(fn, ins)
+		}
+	}
+}
+
+// addCall connects the call to the function. argsOffset is the amount of parameters of the function that aren't
+// passed as arguments of the call, like the receiver of an interface method.
+func (s *solver) addCall(site ssa.CallInstruction, fn *ssa.Function, argsOffset int) {
+	key := callEdge{site: site, fn: fn}
+	if _, ok := s.calls[key]; ok {
+		return
+	}
+	s.calls[key] = struct{}{}
+	s.callees[site.Common()] = append(s.callees[site.Common()], fn)
+	s.addReachable(fn)
+
+	for i, arg := range site.Common().Args {
+		if i+argsOffset >= len(fn.Params) {
+			break
+		}
+		param := fn.Params[i+argsOffset]
+		for _, path := range s.pointerPaths(param.Type()) {
+			s.addEdge(s.valueNode(arg, path), s.valueNode(param, path))
+		}
+	}
+	result := site.Value()
+	if result == nil {
+		return
+	}
+	for _, path := range s.pointerPaths(result.Type()) {
+		if fn.Blocks == nil {
+			// The body of the function isn't known, so the result points to an object of its own
+			s.addLabel(s.valueNode(result, path), s.labelOf(s.objectOf(result), path))
+			continue
+		}
+		s.addEdge(s.nodeOf(returnOwner{fn: fn}, path), s.valueNode(result, path))
+	}
+}
+
+func (s *solver) addCallInstruction(site ssa.CallInstruction) {
+	call := site.Common()
+	if call.IsInvoke() {
+		s.addConstraint(s.valueNode(call.Value, ""), invokeConstraint{site: site})
+		return
+	}
+	switch callee := call.Value.(type) {
+	case *ssa.Builtin:
+		s.addBuiltin(site, callee)
+	case *ssa.Function:
+		s.addCall(site, callee, 0)
+	case *ssa.MakeClosure:
+		s.addCall(site, callee.Fn.(*ssa.Function), 0)
+	default:
+		s.addConstraint(s.valueNode(call.Value, ""), dynamicCallConstraint{site: site})
+	}
+}
+
+func (s *solver) addBuiltin(site ssa.CallInstruction, builtin *ssa.Builtin) {
+	args := site.Common().Args
+	result := site.Value()
+	switch builtin.Name() {
+	case "append":
+		// The result is either the slice or a new array, holding the elements of both
+		if result == nil {
+			return
+		}
+		s.addLabel(s.valueNode(result, ""), s.labelOf(s.objectOf(result), ""))
+		s.addEdge(s.valueNode(args[0], ""), s.valueNode(result, ""))
+		s.copyElements(site, args[1], result)
+		s.copyElements(site, args[0], result)
+	case "copy":
+		s.copyElements(site, args[1], args[0])
+	case "ssa:wrapnilchk":
+		if result != nil {
+			s.addEdge(s.valueNode(args[0], ""), s.valueNode(result, ""))
+		}
+	}
+}
+
+// copyElements makes the elements of the slice dst point to everything the elements of the slice src point to.
+func (s *solver) copyElements(site ssa.CallInstruction, src, dst ssa.Value) {
+	slice, ok := src.Type().Underlying().(*types.Slice)
+	if !ok { // Appending a string to a slice of bytes
+		return
+	}
+	for _, path := range s.pointerPaths(slice.Elem()) {
+		temp := s.nodeOf(tempOwner{site: site}, path)
+		s.addConstraint(s.valueNode(src, ""), loadConstraint{dst: temp, offset: "[*]" + path})
+		s.addConstraint(s.valueNode(dst, ""), storeConstraint{src: temp, offset: "[*]" + path})
+	}
+}
+
+func (s *solver) copyValue(src, dst ssa.Value) {
+	for _, path := range s.pointerPaths(dst.Type()) {
+		s.addEdge(s.valueNode(src, path), s.valueNode(dst, path))
+	}
+}
+
+func (s *solver) load(address ssa.Value, offset string, typ types.Type, result ssa.Value, prefix string) {
+	for _, path := range s.pointerPaths(typ) {
+		s.addConstraint(s.valueNode(address, ""), loadConstraint{dst: s.valueNode(result, prefix+path), offset: offset + path})
+	}
+}
+
+func (s *solver) store(address ssa.Value, offset string, value ssa.Value) {
+	for _, path := range s.pointerPaths(value.Type()) {
+		s.addConstraint(s.valueNode(address, ""), storeConstraint{src: s.valueNode(value, path), offset: offset + path})
+	}
+}
+
+func (s *solver) allocate(value ssa.Value) {
+	s.addLabel(s.valueNode(value, ""), s.labelOf(s.objectOf(value), ""))
+}
+
+func (s *solver) addInstruction(fn *ssa.Function, ins ssa.Instruction) {
+	switch ins := ins.(type) {
+	case *ssa.Alloc, *ssa.MakeSlice, *ssa.MakeMap, *ssa.MakeChan:
+		s.allocate(ins.(ssa.Value))
+	case *ssa.MakeInterface:
+		s.allocate(ins)
+		box := s.labelOf(s.objectOf(ins), "")
+		for _, path := range s.pointerPaths(ins.X.Type()) {
+			s.addEdge(s.valueNode(ins.X, path), s.contentsNode(s.extend(box, path)))
+		}
+	case *ssa.MakeClosure:
+		s.allocate(ins)
+		closureFn := ins.Fn.(*ssa.Function)
+		for i, binding := range ins.Bindings {
+			s.copyValue(binding, closureFn.FreeVars[i])
+		}
+	case *ssa.FieldAddr:
+		offset := "." + ssaPureUtils.GetFieldName(ins.X.Type(), ins.Field)
+		s.addConstraint(s.valueNode(ins.X, ""), offsetConstraint{dst: s.valueNode(ins, ""), offset: offset})
+	case *ssa.IndexAddr:
+		s.addConstraint(s.valueNode(ins.X, ""), offsetConstraint{dst: s.valueNode(ins, ""), offset: "[*]"})
+	case *ssa.Field:
+		offset := "." + ssaPureUtils.GetFieldName(ins.X.Type(), ins.Field)
+		for _, path := range s.pointerPaths(ins.Type()) {
+			s.addEdge(s.valueNode(ins.X, offset+path), s.valueNode(ins, path))
+		}
+	case *ssa.Index:
+		for _, path := range s.pointerPaths(ins.Type()) {
+			s.addEdge(s.valueNode(ins.X, "[*]"+path), s.valueNode(ins, path))
+		}
+	case *ssa.Extract:
+		for _, path := range s.pointerPaths(ins.Type()) {
+			s.addEdge(s.valueNode(ins.Tuple, tuplePath(ins.Index)+path), s.valueNode(ins, path))
+		}
+	case *ssa.UnOp:
+		switch ins.Op {
+		case token.MUL:
+			s.load(ins.X, "", ins.Type(), ins, "")
+		case token.ARROW:
+			prefix := ""
+			if ins.CommaOk {
+				prefix = tuplePath(0)
+			}
+			if chanType, ok := ins.X.Type().Underlying().(*types.Chan); ok {
+				s.load(ins.X, "[*]", chanType.Elem(), ins, prefix)
+			}
+		}
+	case *ssa.Store:
+		s.store(ins.Addr, "", ins.Val)
+	case *ssa.Send:
+		s.store(ins.Chan, "[*]", ins.X)
+	case *ssa.MapUpdate:
+		s.store(ins.Map, "[key]", ins.Key)
+		s.store(ins.Map, "[*]", ins.Value)
+	case *ssa.Lookup:
+		if mapType, ok := ins.X.Type().Underlying().(*types.Map); ok {
+			prefix := ""
+			if ins.CommaOk {
+				prefix = tuplePath(0)
+			}
+			s.load(ins.X, "[*]", mapType.Elem(), ins, prefix)
+		}
+	case *ssa.Range:
+		if _, ok := ins.X.Type().Underlying().(*types.Map); ok {
+			s.addEdge(s.valueNode(ins.X, ""), s.valueNode(ins, ""))
+		}
+	case *ssa.Next:
+		if ins.IsString {
+			return
+		}
+		if mapType, ok := ins.Iter.(*ssa.Range).X.Type().Underlying().(*types.Map); ok {
+			s.load(ins.Iter, "[key]", mapType.Key(), ins, tuplePath(1))
+			s.load(ins.Iter, "[*]", mapType.Elem(), ins, tuplePath(2))
+		}
+	case *ssa.Select:
+		received := 0
+		for _, state := range ins.States {
+			if state.Dir == types.SendOnly {
+				s.store(state.Chan, "[*]", state.Send)
+				continue
+			}
+			if chanType, ok := state.Chan.Type().Underlying().(*types.Chan); ok {
+				s.load(state.Chan, "[*]", chanType.Elem(), ins, tuplePath(2+received))
+			}
+			received++
+		}
+	case *ssa.Phi:
+		for _, edge := range ins.Edges {
+			s.copyValue(edge, ins)
+		}
+	case *ssa.ChangeType, *ssa.ChangeInterface, *ssa.SliceToArrayPointer:
+		value := ins.(ssa.Value)
+		s.copyValue(*ins.Operands(nil)[0], value)
+	case *ssa.Slice:
+		if CanPoint(ins.X.Type()) { // Strings don't point to objects
+			s.addEdge(s.valueNode(ins.X, ""), s.valueNode(ins, ""))
+		}
+	case *ssa.Convert:
+		s.addConversion(ins.X, ins)
+	case *ssa.MultiConvert:
+		s.addConversion(ins.X, ins)
+	case *ssa.TypeAssert:
+		prefix := ""
+		if ins.CommaOk {
+			prefix = tuplePath(0)
+		}
+		s.addConstraint(s.valueNode(ins.X, ""), typeAssertConstraint{typ: ins.AssertedType, result: ins, prefix: prefix})
+	case ssa.CallInstruction:
+		s.addCallInstruction(ins)
+	case *ssa.Return:
+		for i, result := range ins.Results {
+			for _, path := range s.pointerPaths(result.Type()) {
+				s.addEdge(s.valueNode(result, path), s.nodeOf(returnOwner{fn: fn}, resultPath(fn.Signature, i)+path))
+			}
+		}
+	}
+}
+
+// addConversion keeps the pointers of conversions between pointer-like types, like to unsafe.Pointer. A conversion of
+// a value that isn't pointer-like, like a string to a slice of bytes, allocates a new object.
+func (s *solver) addConversion(x, result ssa.Value) {
+	if !CanPoint(result.Type()) {
+		return
+	}
+	if CanPoint(x.Type()) {
+		s.addEdge(s.valueNode(x, ""), s.valueNode(result, ""))
+		return
+	}
+	s.allocate(result)
+}
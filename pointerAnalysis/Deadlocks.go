@@ -0,0 +1,193 @@
+package pointerAnalysis
+
+import (
+	"github.com/pdufour/Chronos/domain"
+	"golang.org/x/tools/go/ssa"
+	"sort"
+	"strconv"
+)
+
+// Goroutines that acquire the same mutexes in different orders may deadlock. The lock order graph has an edge from each
+// mutex held while acquiring another mutex to the acquired mutex, labeled by the acquisition, which holds the goroutine
+// and the stack it was made in. A cycle in the graph deadlocks if its acquisitions are made by different goroutines
+// that may run concurrently, each holding the mutex the next one waits for. Acquisitions made while holding a common
+// mutex never run together, and a read lock doesn't wait for a mutex that is only read locked.
+
+// maxCycleLength limits the amount of mutexes in a cycle. Enumerating longer cycles may take exponential time, and
+// deadlocks between more goroutines are rare.
+const maxCycleLength = 4
+
+type mutexKey struct {
+	site ssa.Value
+	path string
+}
+
+func getMutexKey(lockID domain.LockID) mutexKey {
+	return mutexKey{site: lockID.Site, path: lockID.Path}
+}
+
+// lockOrderEdge is the acquisition of a mutex while holding the held mutex.
+type lockOrderEdge struct {
+	held        domain.LockID
+	acquisition *domain.GuardedAccess
+}
+
+type lockOrderGraph struct {
+	mutexes []mutexKey // In the order they were first acquired, so the cycles are found in the same order every time
+	indexes map[mutexKey]int
+	succs   map[mutexKey][]mutexKey
+	edges   map[[2]mutexKey][]*lockOrderEdge
+}
+
+// FindDeadlocks returns the cycles of acquisitions of mutexes that may deadlock. Each acquisition of a cycle is made
+// while holding the mutex acquired by the previous one, and the first one holds the mutex acquired by the last one.
+func FindDeadlocks(accesses []*domain.GuardedAccess) [][]*domain.GuardedAccess {
+	graph := newLockOrderGraph(accesses)
+	deadlocks := make([][]*domain.GuardedAccess, 0)
+	for start := range graph.mutexes {
+		for _, cycle := range graph.getCycles(start) {
+			if acquisitions := graph.getDeadlock(cycle); acquisitions != nil {
+				deadlocks = append(deadlocks, acquisitions)
+			}
+		}
+	}
+	return deadlocks
+}
+
+func newLockOrderGraph(accesses []*domain.GuardedAccess) *lockOrderGraph {
+	graph := &lockOrderGraph{
+		indexes: make(map[mutexKey]int),
+		succs:   make(map[mutexKey][]mutexKey),
+		edges:   make(map[[2]mutexKey][]*lockOrderEdge),
+	}
+	for _, acquisition := range accesses {
+		if acquisition.OpKind != domain.GuardAccessLock {
+			continue
+		}
+		to := getMutexKey(acquisition.Mutex)
+		if _, ok := graph.indexes[to]; !ok {
+			graph.indexes[to] = len(graph.mutexes)
+			graph.mutexes = append(graph.mutexes, to)
+		}
+		for held := range acquisition.Lockset.Locks {
+			from := getMutexKey(held)
+			if from == to { // Locking a mutex that is already held blocks by itself, regardless of the order
+				continue
+			}
+			key := [2]mutexKey{from, to}
+			if _, ok := graph.edges[key]; !ok {
+				graph.succs[from] = append(graph.succs[from], to)
+			}
+			graph.edges[key] = append(graph.edges[key], &lockOrderEdge{held: held, acquisition: acquisition})
+		}
+	}
+	for from, succs := range graph.succs {
+		sort.Slice(succs, func(i, j int) bool {
+			return graph.indexes[succs[i]] < graph.indexes[succs[j]]
+		})
+		graph.succs[from] = succs
+	}
+	return graph
+}
+
+// getCycles returns the cycles of mutexes that start from the mutex at the index, and go through mutexes acquired
+// after it only, so each cycle is found from its first mutex only.
+func (g *lockOrderGraph) getCycles(start int) [][]mutexKey {
+	cycles := make([][]mutexKey, 0)
+	path := []mutexKey{g.mutexes[start]}
+	isOnPath := map[mutexKey]bool{g.mutexes[start]: true}
+	var visit func(mutex mutexKey)
+	visit = func(mutex mutexKey) {
+		for _, succ := range g.succs[mutex] {
+			if succ == g.mutexes[start] {
+				cycles = append(cycles, append([]mutexKey{}, path...))
+				continue
+			}
+			if g.indexes[succ] < start || isOnPath[succ] || len(path) == maxCycleLength {
+				continue
+			}
+			path = append(path, succ)
+			isOnPath[succ] = true
+			visit(succ)
+			isOnPath[succ] = false
+			path = path[:len(path)-1]
+		}
+	}
+	visit(g.mutexes[start])
+	return cycles
+}
+
+// getDeadlock returns an acquisition for each edge of the cycle, such that the acquisitions may deadlock, or nil if
+// there aren't such acquisitions.
+func (g *lockOrderGraph) getDeadlock(cycle []mutexKey) []*domain.GuardedAccess {
+	chosen := make([]*lockOrderEdge, 0, len(cycle))
+	var choose func(i int) bool
+	choose = func(i int) bool {
+		if i == len(cycle) {
+			return isWaiting(chosen[len(chosen)-1], chosen[0])
+		}
+		for _, edge := range g.edges[[2]mutexKey{cycle[i], cycle[(i+1)%len(cycle)]}] {
+			if !isDeadlockingWith(chosen, edge) {
+				continue
+			}
+			chosen = append(chosen, edge)
+			if choose(i + 1) {
+				return true
+			}
+			chosen = chosen[:len(chosen)-1]
+		}
+		return false
+	}
+	if !choose(0) {
+		return nil
+	}
+	acquisitions := make([]*domain.GuardedAccess, 0, len(chosen))
+	for _, edge := range chosen {
+		acquisitions = append(acquisitions, edge.acquisition)
+	}
+	return acquisitions
+}
+
+// isDeadlockingWith returns whether the acquisition of the edge may run together with the acquisitions chosen before
+// it, and waits for the mutex held by the next one.
+func isDeadlockingWith(chosen []*lockOrderEdge, edge *lockOrderEdge) bool {
+	for _, chosenEdge := range chosen {
+		acquisitionA, acquisitionB := chosenEdge.acquisition, edge.acquisition
+		if acquisitionA.State.GoroutineID == acquisitionB.State.GoroutineID || !acquisitionA.State.MayConcurrent(acquisitionB.State) {
+			return false
+		}
+		if acquisitionA.Lockset.IsProtecting(acquisitionB.Lockset) {
+			return false
+		}
+	}
+	return len(chosen) == 0 || isWaiting(chosen[len(chosen)-1], edge)
+}
+
+// isWaiting returns whether the acquisition of the edge waits for the mutex held by the next edge. Read locks only wait
+// for write locks.
+func isWaiting(edge, next *lockOrderEdge) bool {
+	return !edge.acquisition.Mutex.IsRead || !next.held.IsRead
+}
+
+// FilterDuplicateDeadlocks removes the deadlocks whose acquisitions are at the same positions as an earlier deadlock.
+func FilterDuplicateDeadlocks(deadlocks [][]*domain.GuardedAccess) [][]*domain.GuardedAccess {
+	found := make(map[string]struct{})
+	nonDuplicates := make([][]*domain.GuardedAccess, 0)
+	for _, deadlock := range deadlocks {
+		positions := make([]int, 0, len(deadlock))
+		for _, acquisition := range deadlock {
+			positions = append(positions, int(acquisition.Pos))
+		}
+		sort.Ints(positions)
+		key := ""
+		for _, pos := range positions {
+			key += strconv.Itoa(pos) + ","
+		}
+		if _, ok := found[key]; ok {
+			continue
+		}
+		found[key] = struct{}{}
+		nonDuplicates = append(nonDuplicates, deadlock)
+	}
+	return nonDuplicates
+}
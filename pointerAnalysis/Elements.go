@@ -0,0 +1,89 @@
+package pointerAnalysis
+
+import (
+	"github.com/pdufour/Chronos/domain"
+	"go/constant"
+	"go/token"
+	"golang.org/x/tools/go/ssa"
+)
+
+// All the elements of an array share a single location in the points-to analysis, so accesses to elements are told
+// apart by their indices instead. Two accesses access different elements if their indices are different constants, or
+// if they're made by instances of a goroutine started in a loop, through the same parameter bound to the index of the
+// loop. Slicing an array from an offset other than zero shifts the indices of the elements, so accesses to arrays that
+// are sliced this way are never told apart.
+
+// isDisjointElements returns whether the accesses are to different elements of the same arrays.
+func isDisjointElements(result *Result, guardedAccessA, guardedAccessB *domain.GuardedAccess) bool {
+	if guardedAccessA.Index == nil || guardedAccessB.Index == nil {
+		return false
+	}
+	if !isDistinctIndex(guardedAccessA.Index, guardedAccessB.Index) &&
+		!guardedAccessA.State.IsOtherIteration(guardedAccessB.State, guardedAccessA.Index, guardedAccessB.Index) {
+		return false
+	}
+	return !result.isResliced(getArray(guardedAccessA.Value)) && !result.isResliced(getArray(guardedAccessB.Value))
+}
+
+func isDistinctIndex(indexA, indexB ssa.Value) bool {
+	constA, okA := indexA.(*ssa.Const)
+	constB, okB := indexB.(*ssa.Const)
+	if !okA || !okB || constA.Value == nil || constB.Value == nil || constA.Value.Kind() != constB.Value.Kind() {
+		return false
+	}
+	return constant.Compare(constA.Value, token.NEQ, constB.Value)
+}
+
+// getArray returns the array or the slice whose element is accessed. Writes are recorded with the address of the
+// element, and reads with the array itself.
+func getArray(value ssa.Value) ssa.Value {
+	if element, ok := value.(*ssa.IndexAddr); ok {
+		return element.X
+	}
+	return value
+}
+
+// isResliced returns whether the array may be sliced from an offset other than zero, or if it isn't known which arrays
+// it may be.
+func (r *Result) isResliced(array ssa.Value) bool {
+	if r.resliced == nil {
+		r.resliced = r.solver.getReslicedObjects()
+	}
+	nodeID, ok := r.solver.nodeIDs[nodeKey{owner: array, path: ""}]
+	if !ok || len(r.solver.nodes[nodeID].ptsList) == 0 {
+		return true
+	}
+	for _, labelID := range r.solver.nodes[nodeID].ptsList {
+		if _, ok := r.resliced[r.solver.labels[labelID].obj]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *solver) getReslicedObjects() map[*object]struct{} {
+	resliced := make(map[*object]struct{})
+	for fn := range s.reachable {
+		for _, block := range fn.Blocks {
+			for _, ins := range block.Instrs {
+				slice, ok := ins.(*ssa.Slice)
+				if !ok || slice.Low == nil || isZero(slice.Low) {
+					continue
+				}
+				nodeID, ok := s.nodeIDs[nodeKey{owner: slice.X, path: ""}]
+				if !ok {
+					continue
+				}
+				for _, labelID := range s.nodes[nodeID].ptsList {
+					resliced[s.labels[labelID].obj] = struct{}{}
+				}
+			}
+		}
+	}
+	return resliced
+}
+
+func isZero(value ssa.Value) bool {
+	c, ok := value.(*ssa.Const)
+	return ok && c.Value != nil && c.Value.Kind() == constant.Int && constant.Sign(c.Value) == 0
+}
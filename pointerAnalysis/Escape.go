@@ -0,0 +1,117 @@
+package pointerAnalysis
+
+import (
+	"github.com/pdufour/Chronos/ssaPureUtils"
+	"golang.org/x/tools/go/ssa"
+)
+
+// The escape analysis finds the objects that more than one goroutine may reach. Globals are shared, and so are the
+// objects passed to a goroutine when it starts: the arguments of the go statement, the function value it calls and the
+// variables its closure captures. Everything stored in a shared object, or captured by a shared closure, is shared too.
+// The other objects are reachable only by the goroutine that allocated them, so accesses to them never race.
+
+// IsShared returns whether the value may point to an object that more than one goroutine may reach. Values that aren't
+// known to point to any object, like values of functions the analysis didn't reach, are assumed to be shared.
+func (r *Result) IsShared(value ssa.Value) bool {
+	if r.shared == nil {
+		r.shared = r.solver.getSharedObjects()
+	}
+	nodeID, ok := r.solver.nodeIDs[nodeKey{owner: value, path: ""}]
+	if !ok || len(r.solver.nodes[nodeID].ptsList) == 0 {
+		return true
+	}
+	for _, labelID := range r.solver.nodes[nodeID].ptsList {
+		if _, ok := r.shared[r.solver.labels[labelID].obj]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *solver) getSharedObjects() map[*object]struct{} {
+	e := &escape{solver: s, shared: make(map[*object]struct{}), labels: make(map[*object][]int)}
+	for labelID, l := range s.labels {
+		e.labels[l.obj] = append(e.labels[l.obj], labelID)
+	}
+	for _, obj := range s.objects {
+		if _, ok := obj.site.(*ssa.Global); ok {
+			e.share(obj)
+		}
+	}
+	for fn := range s.reachable {
+		for _, block := range fn.Blocks {
+			for _, ins := range block.Instrs {
+				if call, ok := ins.(ssa.CallInstruction); ok && isStartingGoroutine(call) {
+					e.shareCall(call.Common())
+				}
+			}
+		}
+	}
+
+	for len(e.queue) > 0 {
+		obj := e.queue[len(e.queue)-1]
+		e.queue = e.queue[:len(e.queue)-1]
+		for _, labelID := range e.labels[obj] {
+			if nodeID, ok := s.nodeIDs[nodeKey{owner: contentsOwner{labelID: labelID}, path: ""}]; ok {
+				e.shareNode(nodeID)
+			}
+		}
+		if closure, ok := obj.site.(*ssa.MakeClosure); ok {
+			for _, binding := range closure.Bindings {
+				e.shareValue(binding)
+			}
+		}
+	}
+	return e.shared
+}
+
+// isStartingGoroutine returns whether the call runs a function in another goroutine, either by a go statement or by the
+// testing functions that run subtests in goroutines of their own.
+func isStartingGoroutine(call ssa.CallInstruction) bool {
+	if _, ok := call.(*ssa.Go); ok {
+		return true
+	}
+	callee := call.Common().StaticCallee()
+	return callee != nil && (ssaPureUtils.IsTestRun(callee) || ssaPureUtils.IsRunParallel(callee) || ssaPureUtils.IsFuzz(callee))
+}
+
+type escape struct {
+	solver *solver
+	shared map[*object]struct{}
+	labels map[*object][]int // The labels inside each object
+	queue  []*object         // Shared objects whose contents weren't shared yet
+}
+
+func (e *escape) share(obj *object) {
+	if _, ok := e.shared[obj]; ok {
+		return
+	}
+	e.shared[obj] = struct{}{}
+	e.queue = append(e.queue, obj)
+}
+
+func (e *escape) shareNode(nodeID int) {
+	for _, labelID := range e.solver.nodes[nodeID].ptsList {
+		e.share(e.solver.labels[labelID].obj)
+	}
+}
+
+func (e *escape) shareValue(value ssa.Value) {
+	for _, path := range e.solver.pointerPaths(value.Type()) {
+		if nodeID, ok := e.solver.nodeIDs[nodeKey{owner: value, path: path}]; ok {
+			e.shareNode(nodeID)
+		}
+	}
+}
+
+func (e *escape) shareCall(call *ssa.CallCommon) {
+	e.shareValue(call.Value)
+	for _, arg := range call.Args {
+		e.shareValue(arg)
+	}
+	if closure, ok := call.Value.(*ssa.MakeClosure); ok {
+		for _, binding := range closure.Bindings {
+			e.shareValue(binding)
+		}
+	}
+}
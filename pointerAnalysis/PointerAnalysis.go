@@ -4,70 +4,191 @@ import (
 	"github.com/pdufour/Chronos/domain"
 	"github.com/pdufour/Chronos/utils"
 	"go/token"
-	"golang.org/x/tools/go/pointer"
 	"golang.org/x/tools/go/ssa"
 )
 
+// Allocation identifies an object by the site it was allocated in, and the path to the object inside the allocation,
+// for example a field of a struct.
+type Allocation struct {
+	Site ssa.Value
+	Path string
+}
+
+// PointsTo maps values to the allocations they may point to.
+type PointsTo map[ssa.Value][]Allocation
+
+// Result holds the solved points-to sets of a program.
+type Result struct {
+	solver   *solver
+	shared   map[*object]struct{} // The objects more than one goroutine may reach, found when they're first needed
+	resliced map[*object]struct{} // The arrays sliced from an offset other than zero, found when they're first needed
+}
+
+// Analyze runs the points-to analysis on the functions reachable from the main functions of the program.
+func Analyze(prog *ssa.Program) *Result {
+	s := newSolver(prog)
+	for _, pkg := range prog.AllPackages() {
+		if pkg.Func("main") == nil {
+			continue
+		}
+		s.addReachable(pkg.Func("main"))
+		if initFunc := pkg.Func("init"); initFunc != nil {
+			s.addReachable(initFunc)
+		}
+	}
+	s.solve()
+	return &Result{solver: s}
+}
+
+// PointsTo returns the allocations the value may point to, ordered by the order they were found in.
+func (r *Result) PointsTo(value ssa.Value) []Allocation {
+	allocs := make([]Allocation, 0)
+	nodeID, ok := r.solver.nodeIDs[nodeKey{owner: value, path: ""}]
+	if !ok {
+		return allocs
+	}
+	for _, labelID := range r.solver.nodes[nodeID].ptsList {
+		l := r.solver.labels[labelID]
+		allocs = append(allocs, Allocation{Site: l.obj.site, Path: l.path})
+	}
+	return allocs
+}
+
+// Callees returns the functions that may be called by the call, ordered by the order they were found in. Calls of
+// function values, like closures stored in fields, are resolved by the functions the value may point to.
+func (r *Result) Callees(call *ssa.CallCommon) []*ssa.Function {
+	return r.solver.callees[call]
+}
+
+// GetPointsToSets returns the points-to sets of the values, so objects such as channels can be identified by the
+// allocation they refer to and not by the value used to access them.
+func GetPointsToSets(result *Result, values []ssa.Value) PointsTo {
+	pointsTo := make(PointsTo)
+	for _, value := range values {
+		if CanPoint(value.Type()) {
+			pointsTo[value] = result.PointsTo(value)
+		}
+	}
+	return pointsTo
+}
+
+// accessKey is the position of a value, and the instance of a generic function the value is in. Instances of the same
+// generic function share its positions, but their values are told apart since they have different types.
+type accessKey struct {
+	pos      token.Pos
+	instance *ssa.Function
+}
+
+func getAccessKey(value ssa.Value) accessKey {
+	key := accessKey{pos: value.Pos()}
+	if member, ok := value.(interface{ Parent() *ssa.Function }); ok {
+		if parent := member.Parent(); parent != nil && parent.Origin() != nil {
+			key.instance = parent
+		}
+	}
+	return key
+}
 
 // Analysis starts by mapping between positions of the guard accesses (values inside) to the guard accesses themselves.
 // Then it analyzes all the values inside values inside, and check if some of the values might alias each other. If so,
 // the positions for those values are merged. After all positions were merged, the algorithm runs and check if for a
 // given value (identified by a pos in the map) there are two guarded accesses that might conflict - W&W/R&W from two
 // different goroutines.
-// map1 : A->ga1, ga2, ga3
-//        B->ga4, ga5, ga6
-//        C->ga7, ga8, ga9
-//        D->ga10, ga11, ga12
+//
+//	map1 : A->ga1, ga2, ga3
+//	       B->ga4, ga5, ga6
+//	       C->ga7, ga8, ga9
+//	       D->ga10, ga11, ga12
+//
 // Now that we know that B may point to A, we add it to it
-// map1 : A->ga1, ga2, ga3, ga4, ga5, ga6
-//        C->ga7, ga8, ga9
-//        D->ga10, ga11, ga12
+//
+//	map1 : A->ga1, ga2, ga3, ga4, ga5, ga6
+//	       C->ga7, ga8, ga9
+//	       D->ga10, ga11, ga12
+//
 // And if A may point to D, then
-// map1 : C->ga7, ga8, ga9
-//        D->ga10, ga11, ga12, ga1, ga2, ga3, ga4, ga5, ga6
+//
+//	map1 : C->ga7, ga8, ga9
+//	       D->ga10, ga11, ga12, ga1, ga2, ga3, ga4, ga5, ga6
+//
 // And then for pos all the guarded accesses are compared to see if data races might exist
-
-func Analysis(pkg *ssa.Package, accesses []*domain.GuardedAccess) ([][]*domain.GuardedAccess, error) {
-	config := &pointer.Config{
-		Mains: []*ssa.Package{pkg},
-	}
-
-	positionsToGuardAccesses := map[token.Pos][]*domain.GuardedAccess{}
+func Analysis(result *Result, accesses []*domain.GuardedAccess) [][]*domain.GuardedAccess {
+	positionsToGuardAccesses := map[accessKey][]*domain.GuardedAccess{}
+	queries := make([]ssa.Value, 0)
 	for _, guardedAccess := range accesses {
-		if guardedAccess.Pos.IsValid() && pointer.CanPoint(guardedAccess.Value.Type()) {
-			config.AddQuery(guardedAccess.Value)
+		if guardedAccess.OpKind == domain.GuardAccessLock { // Acquisitions are checked for the lock order only
+			continue
+		}
+		if guardedAccess.Pos.IsValid() && CanPoint(guardedAccess.Value.Type()) {
+			queries = append(queries, guardedAccess.Value)
 			// Multiple instructions for the same variable for example write and multiple reads
-			positionsToGuardAccesses[guardedAccess.Value.Pos()] = append(positionsToGuardAccesses[guardedAccess.Value.Pos()], guardedAccess)
+			key := getAccessKey(guardedAccess.Value)
+			positionsToGuardAccesses[key] = append(positionsToGuardAccesses[key], guardedAccess)
 		}
 	}
 
-	result, err := pointer.Analyze(config)
-	if err != nil {
-		return nil, err // internal error in pointer analysis
-	}
-
 	// Join instructions of variables that may point to each other.
-	for v, l := range result.Queries {
-		for _, label := range l.PointsTo().Labels() {
-			allocPos := label.Value().Pos()
-			queryPos := v.Pos()
-			if allocPos == queryPos {
+	isQueried := make(map[ssa.Value]bool)
+	for _, v := range queries {
+		if isQueried[v] {
+			continue
+		}
+		isQueried[v] = true
+		for _, alloc := range result.PointsTo(v) {
+			allocKey := getAccessKey(alloc.Site)
+			queryKey := getAccessKey(v)
+			if allocKey == queryKey {
 				continue
 			}
-			positionsToGuardAccesses[allocPos] = append(positionsToGuardAccesses[allocPos], positionsToGuardAccesses[queryPos]...)
+			positionsToGuardAccesses[allocKey] = append(positionsToGuardAccesses[allocKey], positionsToGuardAccesses[queryKey]...)
 		}
 	}
 	conflictingGA := make([][]*domain.GuardedAccess, 0)
 	for _, guardedAccesses := range positionsToGuardAccesses {
 		for _, guardedAccessA := range guardedAccesses {
 			for _, guardedAccessB := range guardedAccesses {
-				if guardedAccessA.IsConflicting(guardedAccessB) {
+				if guardedAccessA.IsConflicting(guardedAccessB) && mayAccessSame(result, guardedAccessA, guardedAccessB) &&
+					!isDisjointElements(result, guardedAccessA, guardedAccessB) && !isDisjointPaths(result, guardedAccessA, guardedAccessB) {
 					conflictingGA = append(conflictingGA, []*domain.GuardedAccess{guardedAccessA, guardedAccessB})
 				}
 			}
 		}
 	}
-	return conflictingGA, nil
+	return conflictingGA
+}
+
+// mayAccessSame returns whether two accesses in the same bucket may access the same object. Accesses at the same
+// position with different values, like a read of a slice and a write of its element by instances of the same goroutine,
+// access the same object only if the values may point to the same allocation. Values with unknown allocations are
+// assumed to alias.
+func mayAccessSame(result *Result, guardedAccessA, guardedAccessB *domain.GuardedAccess) bool {
+	if guardedAccessA.Pos != guardedAccessB.Pos || guardedAccessA.Value == guardedAccessB.Value {
+		return true
+	}
+	allocsA := result.PointsTo(getAddress(guardedAccessA.Value))
+	allocsB := result.PointsTo(getAddress(guardedAccessB.Value))
+	if len(allocsA) == 0 || len(allocsB) == 0 {
+		return true
+	}
+	sites := make(map[ssa.Value]struct{}, len(allocsA))
+	for _, alloc := range allocsA {
+		sites[alloc.Site] = struct{}{}
+	}
+	for _, alloc := range allocsB {
+		if _, ok := sites[alloc.Site]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// getAddress returns the address a value is accessed through. Reads are recorded with the loaded value, so the address
+// is the operand of the load.
+func getAddress(value ssa.Value) ssa.Value {
+	if load, ok := value.(*ssa.UnOp); ok && load.Op == token.MUL {
+		return load.X
+	}
+	return value
 }
 
 func FilterDuplicates(conflictingGAs [][]*domain.GuardedAccess) [][]*domain.GuardedAccess {
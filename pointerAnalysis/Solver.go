@@ -0,0 +1,372 @@
+package pointerAnalysis
+
+import (
+	"go/types"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/types/typeutil"
+	"strings"
+)
+
+// The points-to analysis is an inclusion-based (Andersen style) analysis over the SSA program. It's flow and context
+// insensitive, and field sensitive: an object is split into the locations of its fields and elements, and a pointer
+// may point to any of them. All the elements of an array, a slice, a map or a channel share a single location.
+//
+// Each pointer-like part of an SSA value is a node holding the set of labels it may point to. A label is a location
+// inside an object, and its contents are a node too. The constraints between the nodes are generated for a function
+// once it's found to be reachable from the main packages, and are solved with a worklist until no set changes.
+
+// object is an abstract memory object, identified by the value that allocates it.
+type object struct {
+	site    ssa.Value
+	dynType types.Type // The dynamic type of the value boxed in an interface, or nil if it isn't known.
+}
+
+// label is a location inside an object, like a field of a struct.
+type label struct {
+	obj  *object
+	path string
+}
+
+// nodeKey identifies a node by its owner, an SSA value or another owner type below, and the path of the pointer-like
+// part inside the owner.
+type nodeKey struct {
+	owner interface{}
+	path  string
+}
+
+type returnOwner struct{ fn *ssa.Function }   // The results of a function
+type contentsOwner struct{ labelID int }      // The contents of the memory at a label
+type tempOwner struct{ site ssa.Instruction } // Intermediate results of builtins
+
+type node struct {
+	pts      map[int]struct{}
+	ptsList  []int // The labels of pts in the order they were added, to keep the analysis deterministic
+	delta    []int // Labels added since the node was last processed
+	copyTo   []int
+	complex  []constraint
+	isQueued bool
+}
+
+// constraint is applied on every label added to the node it's attached to.
+type constraint interface {
+	solve(s *solver, labelID int)
+}
+
+const maxPathDepth = 8
+
+type solver struct {
+	prog       *ssa.Program
+	nodes      []*node
+	nodeIDs    map[nodeKey]int
+	labels     []label
+	labelIDs   map[label]int
+	objects    map[ssa.Value]*object
+	edges      map[[2]int]struct{}
+	reachable  map[*ssa.Function]struct{}
+	pending    []*ssa.Function // Reachable functions whose constraints weren't generated yet
+	calls      map[callEdge]struct{}
+	callees    map[*ssa.CallCommon][]*ssa.Function // The functions called at each call site, in the order they were found
+	worklist   []int
+	pathsCache typeutil.Map
+}
+
+type callEdge struct {
+	site ssa.CallInstruction
+	fn   *ssa.Function
+}
+
+func newSolver(prog *ssa.Program) *solver {
+	return &solver{
+		prog:      prog,
+		nodeIDs:   make(map[nodeKey]int),
+		labelIDs:  make(map[label]int),
+		objects:   make(map[ssa.Value]*object),
+		edges:     make(map[[2]int]struct{}),
+		reachable: make(map[*ssa.Function]struct{}),
+		calls:     make(map[callEdge]struct{}),
+		callees:   make(map[*ssa.CallCommon][]*ssa.Function),
+	}
+}
+
+func (s *solver) solve() {
+	for len(s.worklist) > 0 || len(s.pending) > 0 {
+		if len(s.pending) > 0 {
+			fn := s.pending[len(s.pending)-1]
+			s.pending = s.pending[:len(s.pending)-1]
+			s.addConstraints(fn)
+			continue
+		}
+		nodeID := s.worklist[len(s.worklist)-1]
+		s.worklist = s.worklist[:len(s.worklist)-1]
+		n := s.nodes[nodeID]
+		n.isQueued = false
+		delta := n.delta
+		n.delta = nil
+		for i := 0; i < len(n.copyTo); i++ {
+			s.addLabels(n.copyTo[i], delta)
+		}
+		for i := 0; i < len(n.complex); i++ {
+			for _, labelID := range delta {
+				n.complex[i].solve(s, labelID)
+			}
+		}
+	}
+}
+
+func (s *solver) nodeOf(owner interface{}, path string) int {
+	key := nodeKey{owner: owner, path: path}
+	if nodeID, ok := s.nodeIDs[key]; ok {
+		return nodeID
+	}
+	nodeID := len(s.nodes)
+	s.nodes = append(s.nodes, &node{pts: make(map[int]struct{})})
+	s.nodeIDs[key] = nodeID
+	return nodeID
+}
+
+// valueNode returns the node of the pointer-like part of the value at the path. Functions and globals are the
+// addresses of objects of their own, so they point to them from the start.
+func (s *solver) valueNode(value ssa.Value, path string) int {
+	key := nodeKey{owner: value, path: path}
+	if nodeID, ok := s.nodeIDs[key]; ok {
+		return nodeID
+	}
+	nodeID := s.nodeOf(value, path)
+	switch value.(type) {
+	case *ssa.Function, *ssa.Global:
+		if path == "" {
+			s.addLabel(nodeID, s.labelOf(s.objectOf(value), ""))
+		}
+	}
+	return nodeID
+}
+
+func (s *solver) contentsNode(labelID int) int {
+	return s.nodeOf(contentsOwner{labelID: labelID}, "")
+}
+
+func (s *solver) objectOf(site ssa.Value) *object {
+	obj, ok := s.objects[site]
+	if !ok {
+		obj = &object{site: site}
+		if makeInterface, ok := site.(*ssa.MakeInterface); ok {
+			obj.dynType = makeInterface.X.Type()
+		}
+		s.objects[site] = obj
+	}
+	return obj
+}
+
+func (s *solver) labelOf(obj *object, path string) int {
+	key := label{obj: obj, path: path}
+	if labelID, ok := s.labelIDs[key]; ok {
+		return labelID
+	}
+	labelID := len(s.labels)
+	s.labels = append(s.labels, key)
+	s.labelIDs[key] = labelID
+	return labelID
+}
+
+// extend returns the label of the location at the offset inside the location of the label. Conversions through
+// unsafe.Pointer may make the paths grow with no end, so locations deeper than maxPathDepth are merged with their
+// parent location.
+func (s *solver) extend(labelID int, offset string) int {
+	if offset == "" {
+		return labelID
+	}
+	l := s.labels[labelID]
+	path := l.path + offset
+	if getPathDepth(path) > maxPathDepth {
+		return labelID
+	}
+	return s.labelOf(l.obj, path)
+}
+
+func getPathDepth(path string) int {
+	return strings.Count(path, ".") + strings.Count(path, "[") + strings.Count(path, "#")
+}
+
+func (s *solver) addLabel(nodeID, labelID int) {
+	n := s.nodes[nodeID]
+	if _, ok := n.pts[labelID]; ok {
+		return
+	}
+	n.pts[labelID] = struct{}{}
+	n.ptsList = append(n.ptsList, labelID)
+	n.delta = append(n.delta, labelID)
+	if !n.isQueued {
+		n.isQueued = true
+		s.worklist = append(s.worklist, nodeID)
+	}
+}
+
+func (s *solver) addLabels(nodeID int, labelIDs []int) {
+	for _, labelID := range labelIDs {
+		s.addLabel(nodeID, labelID)
+	}
+}
+
+// addEdge makes the node dst point to everything the node src points to.
+func (s *solver) addEdge(src, dst int) {
+	if src == dst {
+		return
+	}
+	key := [2]int{src, dst}
+	if _, ok := s.edges[key]; ok {
+		return
+	}
+	s.edges[key] = struct{}{}
+	n := s.nodes[src]
+	n.copyTo = append(n.copyTo, dst)
+	s.addLabels(dst, n.ptsList)
+}
+
+func (s *solver) addConstraint(nodeID int, c constraint) {
+	n := s.nodes[nodeID]
+	n.complex = append(n.complex, c)
+	for i := 0; i < len(n.ptsList); i++ {
+		c.solve(s, n.ptsList[i])
+	}
+}
+
+// pointerPaths returns the paths of the pointer-like parts of the type, like the fields of a struct that are pointers.
+// The path of a pointer-like type itself is empty.
+func (s *solver) pointerPaths(typ types.Type) []string {
+	if paths, ok := s.pathsCache.At(typ).([]string); ok {
+		return paths
+	}
+	paths := make([]string, 0)
+	switch underlying := typ.Underlying().(type) {
+	case *types.Pointer, *types.Map, *types.Chan, *types.Signature, *types.Interface, *types.Slice:
+		paths = append(paths, "")
+	case *types.Basic:
+		if underlying.Kind() == types.UnsafePointer {
+			paths = append(paths, "")
+		}
+	case *types.Struct:
+		for i := 0; i < underlying.NumFields(); i++ {
+			field := underlying.Field(i)
+			for _, path := range s.pointerPaths(field.Type()) {
+				paths = append(paths, "."+field.Name()+path)
+			}
+		}
+	case *types.Array:
+		for _, path := range s.pointerPaths(underlying.Elem()) {
+			paths = append(paths, "[*]"+path)
+		}
+	case *types.Tuple:
+		for i := 0; i < underlying.Len(); i++ {
+			for _, path := range s.pointerPaths(underlying.At(i).Type()) {
+				paths = append(paths, tuplePath(i)+path)
+			}
+		}
+	}
+	s.pathsCache.Set(typ, paths)
+	return paths
+}
+
+// CanPoint reports whether values of the type may point to objects.
+func CanPoint(typ types.Type) bool {
+	switch underlying := typ.Underlying().(type) {
+	case *types.Pointer, *types.Map, *types.Chan, *types.Signature, *types.Interface, *types.Slice:
+		return true
+	case *types.Basic:
+		return underlying.Kind() == types.UnsafePointer
+	}
+	return false
+}
+
+// loadConstraint makes dst point to the contents of the location at the offset of each label.
+type loadConstraint struct {
+	dst    int
+	offset string
+}
+
+func (c loadConstraint) solve(s *solver, labelID int) {
+	s.addEdge(s.contentsNode(s.extend(labelID, c.offset)), c.dst)
+}
+
+// storeConstraint makes the contents of the location at the offset of each label point to everything src points to.
+type storeConstraint struct {
+	src    int
+	offset string
+}
+
+func (c storeConstraint) solve(s *solver, labelID int) {
+	s.addEdge(c.src, s.contentsNode(s.extend(labelID, c.offset)))
+}
+
+// offsetConstraint makes dst point to the location at the offset of each label, like the address of a field.
+type offsetConstraint struct {
+	dst    int
+	offset string
+}
+
+func (c offsetConstraint) solve(s *solver, labelID int) {
+	s.addLabel(c.dst, s.extend(labelID, c.offset))
+}
+
+// typeAssertConstraint unboxes the interface values whose dynamic type matches the asserted type.
+type typeAssertConstraint struct {
+	typ    types.Type
+	result ssa.Value
+	prefix string // The path of the asserted value inside the result, when the assertion returns whether it succeeded
+}
+
+func (c typeAssertConstraint) solve(s *solver, labelID int) {
+	dynType := s.labels[labelID].obj.dynType
+	if iface, ok := c.typ.Underlying().(*types.Interface); ok {
+		if dynType == nil || types.Implements(dynType, iface) {
+			s.addLabel(s.valueNode(c.result, c.prefix), labelID)
+		}
+		return
+	}
+	if dynType != nil && !types.Identical(dynType, c.typ) {
+		return
+	}
+	for _, path := range s.pointerPaths(c.typ) {
+		s.addEdge(s.contentsNode(s.extend(labelID, path)), s.valueNode(c.result, c.prefix+path))
+	}
+}
+
+// invokeConstraint calls the method of the dynamic type of each interface value the receiver points to.
+type invokeConstraint struct {
+	site ssa.CallInstruction
+}
+
+func (c invokeConstraint) solve(s *solver, labelID int) {
+	dynType := s.labels[labelID].obj.dynType
+	if dynType == nil {
+		return
+	}
+	call := c.site.Common()
+	selection := s.prog.MethodSets.MethodSet(dynType).Lookup(call.Method.Pkg(), call.Method.Name())
+	if selection == nil {
+		return
+	}
+	fn := s.prog.MethodValue(selection)
+	if fn == nil {
+		return
+	}
+	if len(fn.Params) > 0 {
+		for _, path := range s.pointerPaths(dynType) {
+			s.addEdge(s.contentsNode(s.extend(labelID, path)), s.valueNode(fn.Params[0], path))
+		}
+	}
+	s.addCall(c.site, fn, 1)
+}
+
+// dynamicCallConstraint calls each function the called value points to.
+type dynamicCallConstraint struct {
+	site ssa.CallInstruction
+}
+
+func (c dynamicCallConstraint) solve(s *solver, labelID int) {
+	switch site := s.labels[labelID].obj.site.(type) {
+	case *ssa.Function:
+		s.addCall(c.site, site, 0)
+	case *ssa.MakeClosure:
+		s.addCall(c.site, site.Fn.(*ssa.Function), 0)
+	}
+}
@@ -0,0 +1,334 @@
+package pointerAnalysis
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+	"strings"
+	"testing"
+)
+
+const solverProgram = `package main
+
+type mutex struct{ state int }
+
+type store struct {
+	a, b mutex
+	next *store
+}
+
+type locker interface{ lock() *mutex }
+
+func (s *store) lock() *mutex { return &s.b }
+
+func getA(s *store) *mutex { return &s.a }
+
+func main() {
+	s := &store{}
+	var l locker = s
+	a := getA(s)
+	b := l.lock()
+	f := func() *store { return s.next }
+	s.next = &store{}
+	next := f()
+	_, _, _ = a, b, next
+}
+`
+
+func buildProgram(t *testing.T, src string) *ssa.Package {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "main.go", src, 0)
+	require.NoError(t, err)
+	pkg := types.NewPackage("main", "")
+	ssaPkg, _, err := ssautil.BuildPackage(&types.Config{Importer: importer.Default()}, fset, pkg, []*ast.File{f}, ssa.SanityCheckFunctions)
+	require.NoError(t, err)
+	return ssaPkg
+}
+
+// findValues returns the values of the instructions of the function that match, in the order of the instructions.
+func findValues(fn *ssa.Function, isMatch func(value ssa.Value) bool) []ssa.Value {
+	values := make([]ssa.Value, 0)
+	for _, block := range fn.Blocks {
+		for _, ins := range block.Instrs {
+			if value, ok := ins.(ssa.Value); ok && isMatch(value) {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+func isAlloc(value ssa.Value) bool {
+	_, ok := value.(*ssa.Alloc)
+	return ok
+}
+
+func isLoad(value ssa.Value) bool {
+	load, ok := value.(*ssa.UnOp)
+	return ok && load.Op == token.MUL
+}
+
+func Test_Analyze_FieldsAndCalls(t *testing.T) {
+	pkg := buildProgram(t, solverProgram)
+	mainFunc := pkg.Func("main")
+	result := Analyze(pkg.Prog)
+
+	var storeAlloc ssa.Value
+	for _, block := range mainFunc.Blocks {
+		for _, ins := range block.Instrs {
+			if alloc, ok := ins.(*ssa.Alloc); ok && alloc.Comment == "complit" && storeAlloc == nil {
+				storeAlloc = alloc
+			}
+		}
+	}
+	require.NotNil(t, storeAlloc)
+
+	calls := make([]*ssa.Call, 0)
+	for _, block := range mainFunc.Blocks {
+		for _, ins := range block.Instrs {
+			if call, ok := ins.(*ssa.Call); ok {
+				calls = append(calls, call)
+			}
+		}
+	}
+	require.Len(t, calls, 3)
+
+	// A field of the allocation, through a static call
+	assert.Equal(t, []Allocation{{Site: storeAlloc, Path: ".a"}}, result.PointsTo(calls[0]))
+	// A different field, through an interface method
+	assert.Equal(t, []Allocation{{Site: storeAlloc, Path: ".b"}}, result.PointsTo(calls[1]))
+	// The object stored in a field, loaded in a closure
+	next := result.PointsTo(calls[2])
+	require.Len(t, next, 1)
+	assert.NotEqual(t, storeAlloc, next[0].Site)
+	assert.Equal(t, "", next[0].Path)
+
+	// The callees of the interface method and of the closure called through a variable
+	lock := pkg.Prog.LookupMethod(types.NewPointer(pkg.Type("store").Type()), pkg.Pkg, "lock")
+	assert.Equal(t, []*ssa.Function{lock}, result.Callees(calls[1].Common()))
+	assert.Equal(t, []*ssa.Function{mainFunc.AnonFuncs[0]}, result.Callees(calls[2].Common()))
+}
+
+const escapeProgram = `package main
+
+type node struct{ next *node }
+
+var head *node
+
+func main() {
+	local := &node{}
+	captured := &node{}
+	stored := &node{}
+	captured.next = stored
+	go func() { captured.next = nil }()
+	head = &node{}
+	_ = local.next
+}
+`
+
+func Test_IsShared(t *testing.T) {
+	pkg := buildProgram(t, escapeProgram)
+	result := Analyze(pkg.Prog)
+
+	allocs := make([]ssa.Value, 0)
+	for _, block := range pkg.Func("main").Blocks {
+		for _, ins := range block.Instrs {
+			if alloc, ok := ins.(*ssa.Alloc); ok && alloc.Comment == "complit" {
+				allocs = append(allocs, alloc)
+			}
+		}
+	}
+	require.Len(t, allocs, 4)
+
+	// Allocated and accessed by the main goroutine only
+	assert.False(t, result.IsShared(allocs[0]))
+	// Captured by the closure of the goroutine
+	assert.True(t, result.IsShared(allocs[1]))
+	// Stored in an object captured by the goroutine
+	assert.True(t, result.IsShared(allocs[2]))
+	// Stored in a global
+	assert.True(t, result.IsShared(allocs[3]))
+}
+
+const storeLoadProgram = `package main
+
+type box struct{ p *int }
+
+func main() {
+	x := new(int)
+	y := new(int)
+	b := &box{}
+	b.p = x
+	fromField := b.p
+	q := new(*int)
+	*q = y
+	fromPointer := *q
+	_, _ = fromField, fromPointer
+}
+`
+
+func Test_Analyze_StoreAndLoad(t *testing.T) {
+	pkg := buildProgram(t, storeLoadProgram)
+	mainFunc := pkg.Func("main")
+	result := Analyze(pkg.Prog)
+
+	allocs := findValues(mainFunc, isAlloc)
+	require.Len(t, allocs, 4)
+	x, y := allocs[0], allocs[1]
+	loads := findValues(mainFunc, isLoad)
+	require.Len(t, loads, 2)
+
+	// Loaded from the field the pointer was stored in
+	assert.Equal(t, []Allocation{{Site: x, Path: ""}}, result.PointsTo(loads[0]))
+	// Loaded from the pointer the pointer was stored through
+	assert.Equal(t, []Allocation{{Site: y, Path: ""}}, result.PointsTo(loads[1]))
+}
+
+const typeAssertProgram = `package main
+
+type getter interface{ get() *int }
+
+type ptrBox struct{ p *int }
+
+func (b *ptrBox) get() *int { return b.p }
+
+type valueBox struct{ p *int }
+
+func main() {
+	x := new(int)
+	var i interface{} = &ptrBox{p: x}
+	var j interface{} = valueBox{p: x}
+	asPtr := i.(*ptrBox)
+	asOther, _ := i.(*valueBox)
+	asValue := j.(valueBox).p
+	asGetter := i.(getter)
+	notGetter, _ := j.(getter)
+	_, _, _, _, _ = asPtr, asOther, asValue, asGetter, notGetter
+}
+`
+
+func Test_Analyze_TypeAssert(t *testing.T) {
+	pkg := buildProgram(t, typeAssertProgram)
+	mainFunc := pkg.Func("main")
+	result := Analyze(pkg.Prog)
+
+	allocs := findValues(mainFunc, isAlloc)
+	require.Len(t, allocs, 3)
+	x, ptrBox := allocs[0], allocs[1]
+	boxes := findValues(mainFunc, func(value ssa.Value) bool {
+		_, ok := value.(*ssa.MakeInterface)
+		return ok
+	})
+	require.Len(t, boxes, 2)
+	asserts := findValues(mainFunc, func(value ssa.Value) bool {
+		_, ok := value.(*ssa.TypeAssert)
+		return ok
+	})
+	require.Len(t, asserts, 5)
+	extracts := findValues(mainFunc, func(value ssa.Value) bool {
+		extract, ok := value.(*ssa.Extract)
+		return ok && extract.Index == 0
+	})
+	require.Len(t, extracts, 2)
+	fields := findValues(mainFunc, func(value ssa.Value) bool {
+		_, ok := value.(*ssa.Field)
+		return ok
+	})
+	require.Len(t, fields, 1)
+
+	// The pointer boxed in the interface, unboxed by its dynamic type
+	assert.Equal(t, []Allocation{{Site: ptrBox, Path: ""}}, result.PointsTo(asserts[0]))
+	// A different dynamic type doesn't match
+	assert.Empty(t, result.PointsTo(extracts[0]))
+	// The fields of a struct boxed by value
+	assert.Equal(t, []Allocation{{Site: x, Path: ""}}, result.PointsTo(fields[0]))
+	// Asserting an interface keeps the box of the dynamic types implementing it
+	assert.Equal(t, []Allocation{{Site: boxes[0], Path: ""}}, result.PointsTo(asserts[3]))
+	assert.Empty(t, result.PointsTo(extracts[1]))
+}
+
+const invokeProgram = `package main
+
+type getter interface{ get() *int }
+
+type ptrBox struct{ p *int }
+
+func (b *ptrBox) get() *int { return b.p }
+
+type valueBox struct{ p *int }
+
+func (b valueBox) get() *int { return b.p }
+
+var cond bool
+
+func main() {
+	x := new(int)
+	y := new(int)
+	var g getter = &ptrBox{p: x}
+	if cond {
+		g = valueBox{p: y}
+	}
+	p := g.get()
+	_ = p
+}
+`
+
+func Test_Analyze_Invoke(t *testing.T) {
+	pkg := buildProgram(t, invokeProgram)
+	mainFunc := pkg.Func("main")
+	result := Analyze(pkg.Prog)
+
+	allocs := findValues(mainFunc, isAlloc)
+	require.Len(t, allocs, 4)
+	x, y := allocs[0], allocs[1]
+	calls := findValues(mainFunc, func(value ssa.Value) bool {
+		call, ok := value.(*ssa.Call)
+		return ok && call.Call.IsInvoke()
+	})
+	require.Len(t, calls, 1)
+	call := calls[0].(*ssa.Call)
+
+	// The method of each dynamic type is called, with the receiver boxed by pointer or by value
+	ptrGet := pkg.Prog.LookupMethod(types.NewPointer(pkg.Type("ptrBox").Type()), pkg.Pkg, "get")
+	valueGet := pkg.Prog.LookupMethod(pkg.Type("valueBox").Type(), pkg.Pkg, "get")
+	assert.ElementsMatch(t, []*ssa.Function{ptrGet, valueGet}, result.Callees(call.Common()))
+	assert.ElementsMatch(t, []Allocation{{Site: x, Path: ""}, {Site: y, Path: ""}}, result.PointsTo(call))
+}
+
+func Test_Analyze_MaxPathDepth(t *testing.T) {
+	// Each level is a struct holding the level below it, so the pointer is maxPathDepth+2 fields deep
+	levels := maxPathDepth + 2
+	source := &strings.Builder{}
+	source.WriteString("package main\n\ntype level0 struct{ p *int }\n\n")
+	for i := 1; i < levels; i++ {
+		fmt.Fprintf(source, "type level%d struct{ f level%d }\n\n", i, i-1)
+	}
+	fmt.Fprintf(source, "func main() {\n\tv := &level%d{}\n\tp := &v%s.p\n\t_ = p\n}\n", levels-1, strings.Repeat(".f", levels-1))
+	pkg := buildProgram(t, source.String())
+	mainFunc := pkg.Func("main")
+	result := Analyze(pkg.Prog)
+
+	allocs := findValues(mainFunc, isAlloc)
+	require.Len(t, allocs, 1)
+	fieldAddrs := findValues(mainFunc, func(value ssa.Value) bool {
+		_, ok := value.(*ssa.FieldAddr)
+		return ok
+	})
+	require.Len(t, fieldAddrs, levels)
+
+	for i, fieldAddr := range fieldAddrs[:maxPathDepth] {
+		assert.Equal(t, []Allocation{{Site: allocs[0], Path: strings.Repeat(".f", i+1)}}, result.PointsTo(fieldAddr))
+	}
+	// The fields deeper than the limit are merged with the deepest location
+	deepest := []Allocation{{Site: allocs[0], Path: strings.Repeat(".f", maxPathDepth)}}
+	for _, fieldAddr := range fieldAddrs[maxPathDepth:] {
+		assert.Equal(t, deepest, result.PointsTo(fieldAddr))
+	}
+}
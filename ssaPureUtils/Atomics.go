@@ -0,0 +1,44 @@
+package ssaPureUtils
+
+import (
+	"go/types"
+	"golang.org/x/tools/go/ssa"
+	"strings"
+)
+
+// IsAtomic reports whether the function is an operation of sync/atomic, either a function such as atomic.AddInt64 or a
+// method of a typed atomic such as atomic.Int64.
+func IsAtomic(call *ssa.Function) bool {
+	fn, ok := call.Object().(*types.Func)
+	if !ok || fn.Pkg() == nil {
+		return false
+	}
+	return fn.Pkg().Path() == "sync/atomic"
+}
+
+// IsAtomicLoad reports whether the atomic operation only reads the value. Other operations (Store, Swap, Add,
+// CompareAndSwap, And, Or) modify it.
+func IsAtomicLoad(call *ssa.Function) bool {
+	return strings.HasPrefix(call.Object().Name(), "Load")
+}
+
+// IsAtomicOperand reports whether the address is used only as the operand of atomic operations, like &s.count in
+// atomic.AddInt64(&s.count, 1) or s.count.Add(1) for a typed atomic.
+func IsAtomicOperand(value ssa.Value) bool {
+	referrers := value.Referrers()
+	if referrers == nil || len(*referrers) == 0 {
+		return false
+	}
+	for _, referrer := range *referrers {
+		call, ok := referrer.(ssa.CallInstruction)
+		if !ok {
+			return false
+		}
+		common := call.Common()
+		fn := common.StaticCallee()
+		if fn == nil || !IsAtomic(fn) || len(common.Args) == 0 || common.Args[0] != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,10 @@
+package ssaPureUtils
+
+import (
+	"go/token"
+	"golang.org/x/tools/go/ssa"
+)
+
+func IsReceive(unOp *ssa.UnOp) bool {
+	return unOp.Op == token.ARROW
+}
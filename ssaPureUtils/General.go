@@ -3,14 +3,69 @@ package ssaPureUtils
 import (
 	"go/token"
 	"golang.org/x/tools/go/ssa"
+	"strings"
 )
 
+// IsBoundMethodWrapper reports whether the function is the wrapper of a method value, like mu.Lock. The wrapper calls the
+// method on the receiver it captured as a free variable, and is identified as the method it wraps.
+func IsBoundMethodWrapper(fn *ssa.Function) bool {
+	return strings.HasPrefix(fn.Synthetic, "bound method wrapper for ") && len(fn.FreeVars) == 1
+}
 
-func GetMutexPos(value ssa.Value) token.Pos {
-	val, ok := GetField(value)
-	if !ok {
-		return value.Pos()
+
+// GetMustAliasPath returns the root value the object is reached from and the path of fields from the root to the
+// object. Values with the same root and path must point to the same object.
+func GetMustAliasPath(value ssa.Value) (ssa.Value, string) {
+	path := ""
+	for {
+		switch val := value.(type) {
+		case *ssa.FieldAddr:
+			path = "." + GetUnderlyingObjectFromField(val).Name() + path
+			value = val.X
+		case *ssa.UnOp:
+			global, ok := val.X.(*ssa.Global)
+			if val.Op != token.MUL || !ok {
+				return value, path
+			}
+			// Each load of a global is a different value, so the global itself is used
+			path = "*" + path
+			value = global
+		case *ssa.FreeVar:
+			binding := getFreeVarBinding(val)
+			if binding == nil {
+				return value, path
+			}
+			value = binding
+		default:
+			return value, path
+		}
+	}
+}
+
+// getFreeVarBinding returns the value captured by the free variable, if the closure is created in a single place.
+func getFreeVarBinding(freeVar *ssa.FreeVar) ssa.Value {
+	fn := freeVar.Parent()
+	if fn.Parent() == nil {
+		return nil
+	}
+	index := -1
+	for i, fv := range fn.FreeVars {
+		if fv == freeVar {
+			index = i
+		}
+	}
+	var binding ssa.Value
+	for _, block := range fn.Parent().Blocks {
+		for _, ins := range block.Instrs {
+			makeClosure, ok := ins.(*ssa.MakeClosure)
+			if !ok || makeClosure.Fn != fn {
+				continue
+			}
+			if binding != nil {
+				return nil
+			}
+			binding = makeClosure.Bindings[index]
+		}
 	}
-	obj := GetUnderlyingObjectFromField(val)
-	return obj.Pos()
+	return binding
 }
\ No newline at end of file
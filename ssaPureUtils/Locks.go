@@ -6,10 +6,18 @@ import (
 )
 
 func IsLock(call *ssa.Function) bool {
-	return utils.IsCallTo(call, "(*sync.Mutex).Lock")
+	return utils.IsCallTo(call, "(*sync.Mutex).Lock", "(*sync.RWMutex).Lock")
 }
 
 
 func IsUnlock(call *ssa.Function) bool {
-	return utils.IsCallTo(call, "(*sync.Mutex).Unlock")
+	return utils.IsCallTo(call, "(*sync.Mutex).Unlock", "(*sync.RWMutex).Unlock")
+}
+
+func IsRLock(call *ssa.Function) bool {
+	return utils.IsCallTo(call, "(*sync.RWMutex).RLock")
+}
+
+func IsRUnlock(call *ssa.Function) bool {
+	return utils.IsCallTo(call, "(*sync.RWMutex).RUnlock")
 }
@@ -0,0 +1,44 @@
+package ssaPureUtils
+
+import (
+	"github.com/pdufour/Chronos/utils"
+	"golang.org/x/tools/go/ssa"
+)
+
+func IsOnceDo(call *ssa.Function) bool {
+	return utils.IsCallTo(call, "(*sync.Once).Do")
+}
+
+// IsOnceFunc reports whether the function wraps a function so it's called only once, like sync.OnceFunc.
+func IsOnceFunc(call *ssa.Function) bool {
+	return utils.IsCallTo(call, "sync.OnceFunc", "sync.OnceValue", "sync.OnceValues")
+}
+
+// GetOnceFuncCall returns the call to sync.OnceFunc, sync.OnceValue or sync.OnceValues that created the function value.
+// The value is either the result of the call, or a global the result was stored in when the package was initialized.
+func GetOnceFuncCall(value ssa.Value) *ssa.Call {
+	switch value := value.(type) {
+	case *ssa.Call:
+		if fn := value.Call.StaticCallee(); fn != nil && IsOnceFunc(fn) {
+			return value
+		}
+	case *ssa.UnOp:
+		global, ok := value.X.(*ssa.Global)
+		if !ok {
+			return nil
+		}
+		// Globals have no referrers, so the value is looked for in the initialization of the package
+		initFunc := global.Pkg.Func("init")
+		if initFunc == nil {
+			return nil
+		}
+		for _, block := range initFunc.Blocks {
+			for _, ins := range block.Instrs {
+				if store, ok := ins.(*ssa.Store); ok && store.Addr == global {
+					return GetOnceFuncCall(store.Val)
+				}
+			}
+		}
+	}
+	return nil
+}
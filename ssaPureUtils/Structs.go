@@ -1,8 +1,11 @@
 package ssaPureUtils
 
 import (
+	"go/token"
 	"go/types"
 	"golang.org/x/tools/go/ssa"
+	"strconv"
+	"strings"
 )
 
 func GetField(value ssa.Value) (*ssa.FieldAddr, bool) {
@@ -14,18 +17,84 @@ func GetUnderlyingObjectFromField(fieldAddr *ssa.FieldAddr) *types.Var {
 	return fieldAddr.X.Type().Underlying().(*types.Pointer).Elem().Underlying().(*types.Struct).Field(fieldAddr.Field)
 }
 
-func FilterStructs(valueA, valueB ssa.Value) bool {
-	fieldAddrA, okA := GetField(valueA)
-	fieldAddrB, okB := GetField(valueB)
+// GetFieldName returns the name of the field of the struct, or of the struct the type points to.
+func GetFieldName(typ types.Type, field int) string {
+	if pointer, ok := typ.Underlying().(*types.Pointer); ok {
+		typ = pointer.Elem()
+	}
+	if structType, ok := typ.Underlying().(*types.Struct); ok {
+		return structType.Field(field).Name()
+	}
+	return strconv.Itoa(field)
+}
 
-	isBothField := okA && okB
-	if isBothField {
-		fieldA := GetUnderlyingObjectFromField(fieldAddrA)
-		fieldB := GetUnderlyingObjectFromField(fieldAddrB)
-		if fieldA != fieldB { // If same struct but different fields
-			return true
+// GetAccessPath returns the pointer the memory accessed through the value is reached from, and the path of fields and
+// elements from the memory the pointer points to, like ".a.b" or ".a[*]". Structs and arrays loaded from memory are
+// read in place, so the path goes on through the load. Loading a pointer reaches another object, so the loaded pointer
+// is where the path starts.
+func GetAccessPath(value ssa.Value) (ssa.Value, string) {
+	switch val := value.(type) {
+	case *ssa.FieldAddr:
+		base, path := GetAccessPath(val.X)
+		return base, path + "." + GetFieldName(val.X.Type(), val.Field)
+	case *ssa.Field:
+		base, path := GetAccessPath(val.X)
+		return base, path + "." + GetFieldName(val.X.Type(), val.Field)
+	case *ssa.IndexAddr:
+		base, path := GetAccessPath(val.X)
+		return base, path + "[*]"
+	case *ssa.Index:
+		base, path := GetAccessPath(val.X)
+		return base, path + "[*]"
+	case *ssa.UnOp:
+		if val.Op == token.MUL && isAggregate(val.Type()) {
+			return GetAccessPath(val.X)
 		}
 	}
+	return value, ""
+}
 
+func isAggregate(typ types.Type) bool {
+	switch typ.Underlying().(type) {
+	case *types.Struct, *types.Array:
+		return true
+	}
 	return false
 }
+
+// IsOverlappingPaths returns whether the memory at one path inside an object contains the memory at the other, like
+// a struct and one of its fields.
+func IsOverlappingPaths(pathA, pathB string) bool {
+	if len(pathA) > len(pathB) {
+		pathA, pathB = pathB, pathA
+	}
+	if !strings.HasPrefix(pathB, pathA) {
+		return false
+	}
+	return len(pathA) == len(pathB) || pathB[len(pathA)] == '.' || pathB[len(pathA)] == '[' || pathB[len(pathA)] == '#'
+}
+
+// IsAddressOperand reports whether the address is used only to compute the addresses of the fields and elements inside
+// it, like &a.b in a.b.c = 1. Computing an address doesn't access memory, and the access through the inner address is
+// recorded by the instruction using it.
+func IsAddressOperand(value ssa.Value) bool {
+	referrers := value.Referrers()
+	if referrers == nil || len(*referrers) == 0 {
+		return false
+	}
+	for _, referrer := range *referrers {
+		switch ref := referrer.(type) {
+		case *ssa.FieldAddr:
+			if ref.X != value {
+				return false
+			}
+		case *ssa.IndexAddr:
+			if ref.X != value {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
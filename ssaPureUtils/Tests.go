@@ -0,0 +1,80 @@
+package ssaPureUtils
+
+import (
+	"github.com/pdufour/Chronos/utils"
+	"golang.org/x/tools/go/ssa"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// IsTestRun reports whether the function runs a subtest or a sub-benchmark.
+func IsTestRun(call *ssa.Function) bool {
+	return utils.IsCallTo(call, "(*testing.T).Run", "(*testing.B).Run")
+}
+
+func IsTestParallel(call *ssa.Function) bool {
+	return utils.IsCallTo(call, "(*testing.T).Parallel")
+}
+
+func IsRunParallel(call *ssa.Function) bool {
+	return utils.IsCallTo(call, "(*testing.B).RunParallel")
+}
+
+func IsFuzz(call *ssa.Function) bool {
+	return utils.IsCallTo(call, "(*testing.F).Fuzz")
+}
+
+// IsTestRoot reports whether go test runs the function, like a TestXxx function or TestMain.
+func IsTestRoot(fn *ssa.Function) bool {
+	sig := fn.Signature
+	if fn.Parent() != nil || sig.Recv() != nil || sig.Params().Len() != 1 || sig.Results().Len() != 0 {
+		return false
+	}
+	paramType := sig.Params().At(0).Type().String()
+	name := fn.Name()
+	if name == "TestMain" {
+		return paramType == "*testing.M"
+	}
+	return isTestName(name, "Test") && paramType == "*testing.T" ||
+		isTestName(name, "Benchmark") && paramType == "*testing.B" ||
+		isTestName(name, "Fuzz") && paramType == "*testing.F"
+}
+
+// isTestName reports whether the name has the prefix and isn't followed by a lowercase letter, like go test requires.
+func isTestName(name, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	if len(name) == len(prefix) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(name[len(prefix):])
+	return !unicode.IsLower(r)
+}
+
+// CallsTestParallel reports whether the test function calls t.Parallel, so it runs concurrently with other parallel
+// tests.
+func CallsTestParallel(value ssa.Value) bool {
+	var fn *ssa.Function
+	switch value := value.(type) {
+	case *ssa.Function:
+		fn = value
+	case *ssa.MakeClosure:
+		fn = value.Fn.(*ssa.Function)
+	default:
+		return false
+	}
+	for _, block := range fn.Blocks {
+		for _, ins := range block.Instrs {
+			call, ok := ins.(*ssa.Call)
+			if !ok {
+				continue
+			}
+			if callee := call.Call.StaticCallee(); callee != nil && IsTestParallel(callee) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,14 @@
+package ssaPureUtils
+
+import (
+	"github.com/pdufour/Chronos/utils"
+	"golang.org/x/tools/go/ssa"
+)
+
+func IsWaitGroupDone(call *ssa.Function) bool {
+	return utils.IsCallTo(call, "(*sync.WaitGroup).Done")
+}
+
+func IsWaitGroupWait(call *ssa.Function) bool {
+	return utils.IsCallTo(call, "(*sync.WaitGroup).Wait")
+}
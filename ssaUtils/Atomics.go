@@ -0,0 +1,21 @@
+package ssaUtils
+
+import (
+	"github.com/pdufour/Chronos/domain"
+	"github.com/pdufour/Chronos/ssaPureUtils"
+	"golang.org/x/tools/go/ssa"
+)
+
+// HandleAtomic adds an atomic access to the address the operation is performed on. For functions of sync/atomic, it's
+// the first argument, and for methods of typed atomics it's the receiver.
+func HandleAtomic(functionState *domain.BlockState, context *domain.Context, call *ssa.CallCommon, fn *ssa.Function) {
+	if len(call.Args) == 0 {
+		return
+	}
+	kind := domain.GuardAccessAtomicWrite
+	if ssaPureUtils.IsAtomicLoad(fn) {
+		kind = domain.GuardAccessAtomicRead
+	}
+	guardedAccess := domain.AddGuardedAccess(call.Pos(), call.Args[0], kind, functionState.Lockset, context)
+	functionState.GuardedAccesses = append(functionState.GuardedAccesses, guardedAccess)
+}
@@ -0,0 +1,192 @@
+package ssaUtils
+
+import (
+	"go/constant"
+	"go/token"
+	"golang.org/x/tools/go/ssa"
+)
+
+// The branches of a function whose condition is known are pruned, so blocks that never run don't add accesses. The
+// feasible edges are found by iterating over the blocks reached so far until no edge is added. A condition is known if
+// it's computed from constants, from phis whose feasible edges all pass the same constant, or from the conditions of
+// the branches taken to reach the block. For example, a block reached only when v == 1 holds knows v is 1.
+
+type edge struct {
+	from, to *ssa.BasicBlock
+}
+
+type branches struct {
+	feasible map[edge]struct{}
+	reached  map[*ssa.BasicBlock]struct{}
+	cache    map[valueInBlock]constant.Value
+	visiting map[valueInBlock]struct{}
+}
+
+type valueInBlock struct {
+	value ssa.Value
+	block *ssa.BasicBlock
+}
+
+// getFeasibleSuccs returns the successors of each reachable block that may run after it.
+func getFeasibleSuccs(fn *ssa.Function) map[*ssa.BasicBlock][]*ssa.BasicBlock {
+	b := &branches{
+		feasible: make(map[edge]struct{}),
+		reached:  map[*ssa.BasicBlock]struct{}{fn.Blocks[0]: {}},
+	}
+	for changed := true; changed; {
+		changed = false
+		b.cache = make(map[valueInBlock]constant.Value)
+		for _, block := range fn.Blocks {
+			if _, ok := b.reached[block]; !ok {
+				continue
+			}
+			for _, succ := range b.getTakenSuccs(block) {
+				if _, ok := b.feasible[edge{from: block, to: succ}]; ok {
+					continue
+				}
+				b.feasible[edge{from: block, to: succ}] = struct{}{}
+				b.reached[succ] = struct{}{}
+				changed = true
+			}
+		}
+	}
+
+	succs := make(map[*ssa.BasicBlock][]*ssa.BasicBlock, len(b.reached))
+	for _, block := range fn.Blocks {
+		if _, ok := b.reached[block]; !ok {
+			continue
+		}
+		succs[block] = make([]*ssa.BasicBlock, 0, len(block.Succs))
+		for _, succ := range block.Succs {
+			if _, ok := b.feasible[edge{from: block, to: succ}]; ok {
+				succs[block] = append(succs[block], succ)
+			}
+		}
+	}
+	return succs
+}
+
+// getTakenSuccs returns the successors of the block that may run, by the condition of its branch if it's known.
+func (b *branches) getTakenSuccs(block *ssa.BasicBlock) []*ssa.BasicBlock {
+	ifInstr, ok := block.Instrs[len(block.Instrs)-1].(*ssa.If)
+	if !ok {
+		return block.Succs
+	}
+	cond := b.eval(ifInstr.Cond, block)
+	if cond == nil || cond.Kind() != constant.Bool {
+		return block.Succs
+	}
+	if constant.BoolVal(cond) {
+		return block.Succs[:1]
+	}
+	return block.Succs[1:]
+}
+
+// eval returns the constant value of the value when it's used in the block, or nil if it's unknown.
+func (b *branches) eval(value ssa.Value, block *ssa.BasicBlock) constant.Value {
+	key := valueInBlock{value: value, block: block}
+	if result, ok := b.cache[key]; ok {
+		return result
+	}
+	if b.visiting == nil {
+		b.visiting = make(map[valueInBlock]struct{})
+	}
+	if _, ok := b.visiting[key]; ok { // The value depends on itself through a loop
+		return nil
+	}
+	b.visiting[key] = struct{}{}
+	result := b.evalValue(value, block)
+	delete(b.visiting, key)
+	b.cache[key] = result
+	return result
+}
+
+func (b *branches) evalValue(value ssa.Value, block *ssa.BasicBlock) constant.Value {
+	if result := b.evalByBranches(value, block); result != nil {
+		return result
+	}
+	switch v := value.(type) {
+	case *ssa.Const:
+		return v.Value
+	case *ssa.UnOp:
+		x := b.eval(v.X, block)
+		if x == nil {
+			return nil
+		}
+		if (v.Op == token.NOT && x.Kind() == constant.Bool) || (v.Op == token.SUB && isNumber(x)) {
+			return constant.UnaryOp(v.Op, x, 0)
+		}
+	case *ssa.BinOp:
+		x := b.eval(v.X, block)
+		y := b.eval(v.Y, block)
+		if x == nil || y == nil || x.Kind() != y.Kind() {
+			return nil
+		}
+		switch v.Op {
+		case token.EQL, token.NEQ:
+			return constant.MakeBool(constant.Compare(x, v.Op, y))
+		case token.LSS, token.LEQ, token.GTR, token.GEQ:
+			if isNumber(x) || x.Kind() == constant.String {
+				return constant.MakeBool(constant.Compare(x, v.Op, y))
+			}
+		case token.ADD, token.SUB, token.MUL:
+			if isNumber(x) {
+				return constant.BinaryOp(x, v.Op, y)
+			}
+		}
+	case *ssa.Phi:
+		return b.evalPhi(v)
+	}
+	return nil
+}
+
+// evalPhi returns the value of the phi if all of its feasible edges pass the same constant.
+func (b *branches) evalPhi(phi *ssa.Phi) constant.Value {
+	var result constant.Value
+	for i, pred := range phi.Block().Preds {
+		if _, ok := b.feasible[edge{from: pred, to: phi.Block()}]; !ok {
+			continue
+		}
+		value := b.eval(phi.Edges[i], pred)
+		if value == nil || (result != nil && (result.Kind() != value.Kind() || !constant.Compare(result, token.EQL, value))) {
+			return nil
+		}
+		result = value
+	}
+	return result
+}
+
+// evalByBranches returns the value of the value in the block, by the conditions of the branches taken on every path to
+// the block. A block that has a single predecessor, which branches to it, runs only when the condition of the branch
+// has the value the edge was taken for, and so do the blocks it dominates.
+func (b *branches) evalByBranches(value ssa.Value, block *ssa.BasicBlock) constant.Value {
+	for dominator := block; dominator != nil; dominator = dominator.Idom() {
+		if len(dominator.Preds) != 1 {
+			continue
+		}
+		pred := dominator.Preds[0]
+		ifInstr, ok := pred.Instrs[len(pred.Instrs)-1].(*ssa.If)
+		if !ok || pred.Succs[0] == pred.Succs[1] {
+			continue
+		}
+		isTrue := dominator == pred.Succs[0]
+		if ifInstr.Cond == value {
+			return constant.MakeBool(isTrue)
+		}
+		cond, ok := ifInstr.Cond.(*ssa.BinOp)
+		if !ok || (cond.Op == token.EQL) != isTrue || (cond.Op != token.EQL && cond.Op != token.NEQ) {
+			continue
+		}
+		if c, ok := cond.Y.(*ssa.Const); ok && cond.X == value && c.Value != nil {
+			return c.Value
+		}
+		if c, ok := cond.X.(*ssa.Const); ok && cond.Y == value && c.Value != nil {
+			return c.Value
+		}
+	}
+	return nil
+}
+
+func isNumber(value constant.Value) bool {
+	return value.Kind() == constant.Int || value.Kind() == constant.Float
+}
@@ -2,72 +2,459 @@ package ssaUtils
 
 import (
 	"github.com/pdufour/Chronos/domain"
+	"github.com/pdufour/Chronos/pointerAnalysis"
 	"github.com/pdufour/Chronos/utils/stacks"
 	"golang.org/x/tools/go/ssa"
 )
 
+// The state of a function is computed in two steps. First, the state of each block is computed once, in the order the
+// blocks run, with the locks the block itself locks and unlocks. The state of the flow, like the clock, goes through
+// the blocks in this order. Then, the locks held at the entry of each block are found by iterating over the control flow
+// graph until they don't change, so the locks carried around loops are found too. The locks held at a block are a must
+// set: a mutex is held only if it's held on every path to the block. The unlocks are a may set, and accesses are
+// collected from all the blocks.
+
 type CFG struct {
-	visitedBlocksStack *stacks.BlockMap
+	blocks      []*ssa.BasicBlock                     // The blocks reachable from the entry of the function, in reverse postorder
+	succs       map[*ssa.BasicBlock][]*ssa.BasicBlock // The successors of each block that may run after it
+	order       map[int]int                           // The position of each block in blocks
+	loopHeaders map[int]int                           // The header of the outermost loop each block is in
+	loopEnds    map[int]int                           // The last block of each loop in reverse postorder, by its header
+	loops       map[int]*loop                         // The state of each loop, by its header
 
 	ComputedBlocks      map[int]*domain.BlockState
 	ComputedDeferBlocks map[int]*domain.BlockState
 }
 
-func newCFG() *CFG {
-	return &CFG{
-		visitedBlocksStack:  stacks.NewBlockMap(),
+// loop is the state of a loop during the traversal of its blocks.
+type loop struct {
+	cfg       *CFG
+	header    int
+	entry     *domain.Context     // The context at the entry of the loop
+	instances []func(done func()) // Traverse other instances of the goroutines started in the loop
+}
+
+// blockFact is the state at the entry or at the exit of a block, on all the paths that reach it.
+type blockFact struct {
+	lockset    *domain.Lockset
+	mayDefers  map[int]struct{} // The blocks whose deferred calls were registered on some of the paths
+	mustDefers map[int]struct{} // The blocks whose deferred calls were registered on all of the paths
+}
+
+func newCFG(fn *ssa.Function) *CFG {
+	succs := getFeasibleSuccs(fn)
+	cfg := &CFG{
+		blocks:              getReversePostorder(fn.Blocks[0], succs),
+		succs:               succs,
+		order:               make(map[int]int),
+		loopHeaders:         make(map[int]int),
+		loopEnds:            make(map[int]int),
+		loops:               make(map[int]*loop),
 		ComputedBlocks:      make(map[int]*domain.BlockState),
 		ComputedDeferBlocks: make(map[int]*domain.BlockState),
 	}
+	for i, block := range cfg.blocks {
+		cfg.order[block.Index] = i
+	}
+	cfg.findLoops()
+	for _, block := range cfg.blocks {
+		if header, ok := cfg.loopHeaders[block.Index]; ok {
+			cfg.loopEnds[header] = block.Index
+		}
+	}
+	return cfg
 }
 
-// CalculateFunctionState works by traversing the tree in a DFS way, similar to the flow of the function when it'll run.
-// It calculates the state of the regular flow of block first, then adds the state of any succeeding blocks in the tree,
-// and finally the block's defer state if exist.
-// The function uses two way to aggregate the states between blocks. If the blocks are adjacent (siblings) to each
-// other, (resulted from a branch) then a merge mechanism is used. If one block is below the other, then an append is
-// performed.
-func (cfg *CFG) CalculateFunctionState(context *domain.Context, block *ssa.BasicBlock) *domain.BlockState {
-	cfg.visitedBlocksStack.Add(block)
-	defer cfg.visitedBlocksStack.Remove(block)
-	cfg.calculateBlockState(context, block)
+// getReversePostorder returns the blocks reachable from the entry, such that a block comes before its successors
+// unless the edge to the successor closes a loop. Successors are visited from the last one, so the first successor of
+// a branch comes first.
+func getReversePostorder(entry *ssa.BasicBlock, succs map[*ssa.BasicBlock][]*ssa.BasicBlock) []*ssa.BasicBlock {
+	type frame struct {
+		block *ssa.BasicBlock
+		next  int
+	}
+	visited := map[int]struct{}{entry.Index: {}}
+	stack := []frame{{block: entry}}
+	postorder := make([]*ssa.BasicBlock, 0)
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		if top.next < len(succs[top.block]) {
+			succ := succs[top.block][len(succs[top.block])-1-top.next]
+			top.next++
+			if _, ok := visited[succ.Index]; !ok {
+				visited[succ.Index] = struct{}{}
+				stack = append(stack, frame{block: succ})
+			}
+			continue
+		}
+		postorder = append(postorder, top.block)
+		stack = stack[:len(stack)-1]
+	}
+	blocks := make([]*ssa.BasicBlock, 0, len(postorder))
+	for i := len(postorder) - 1; i >= 0; i-- {
+		blocks = append(blocks, postorder[i])
+	}
+	return blocks
+}
 
-	// Regular flow
-	blockState := cfg.ComputedBlocks[block.Index]
+// findLoops finds the blocks of each loop, by walking back from the blocks that jump back to the header of the loop.
+// Outer loops come first in reverse postorder, so a block is assigned to the outermost loop it's in.
+func (cfg *CFG) findLoops() {
+	for _, header := range cfg.blocks {
+		headerOrder := cfg.order[header.Index]
+		body := make([]*ssa.BasicBlock, 0)
+		for _, pred := range header.Preds {
+			if predOrder, ok := cfg.order[pred.Index]; ok && predOrder >= headerOrder && cfg.isFeasible(pred, header) {
+				body = append(body, pred)
+			}
+		}
+		if len(body) == 0 {
+			continue
+		}
+		inLoop := map[int]struct{}{header.Index: {}}
+		for len(body) > 0 {
+			block := body[len(body)-1]
+			body = body[:len(body)-1]
+			if _, ok := inLoop[block.Index]; ok {
+				continue
+			}
+			inLoop[block.Index] = struct{}{}
+			for _, pred := range block.Preds {
+				if predOrder, ok := cfg.order[pred.Index]; ok && predOrder > headerOrder && cfg.isFeasible(pred, block) {
+					body = append(body, pred)
+				}
+			}
+		}
+		for blockIndex := range inLoop {
+			if _, ok := cfg.loopHeaders[blockIndex]; !ok {
+				cfg.loopHeaders[blockIndex] = header.Index
+			}
+		}
+	}
+}
+
+// getLoopIteration returns the parameters of the goroutine that are bound to the variables of the innermost loop the go
+// statement is in. The variables of a loop are the phis of its header, which change in each iteration. The variables of
+// outer loops keep their value during the iterations of the inner loop, so the instances may share them.
+func getLoopIteration(goCall *ssa.Go) *domain.LoopIteration {
+	iteration := &domain.LoopIteration{Params: make(map[*ssa.Parameter]struct{})}
+	callee := goCall.Call.StaticCallee()
+	header := getInnermostLoopHeader(goCall.Block())
+	if callee == nil || header == nil || len(callee.Params) != len(goCall.Call.Args) {
+		return iteration
+	}
+	for i, arg := range goCall.Call.Args {
+		if phi, ok := arg.(*ssa.Phi); ok && phi.Block() == header {
+			iteration.Params[callee.Params[i]] = struct{}{}
+		}
+	}
+	return iteration
+}
+
+// getInnermostLoopHeader returns the header of the innermost loop the block is in, or nil if it isn't in a loop. The
+// headers of the loops the block is in dominate it, and the header of an inner loop is dominated by the outer ones.
+func getInnermostLoopHeader(block *ssa.BasicBlock) *ssa.BasicBlock {
+	for header := block; header != nil; header = header.Idom() {
+		if isLoopHeader(header) && isInLoop(block, header) {
+			return header
+		}
+	}
+	return nil
+}
+
+// isLoopHeader returns whether the block is the target of an edge that closes a loop.
+func isLoopHeader(block *ssa.BasicBlock) bool {
+	for _, pred := range block.Preds {
+		if block.Dominates(pred) {
+			return true
+		}
+	}
+	return false
+}
+
+// isInLoop returns whether the block is in the loop of the header, which means it reaches the header again without
+// leaving the blocks the header dominates.
+func isInLoop(block, header *ssa.BasicBlock) bool {
+	visited := map[*ssa.BasicBlock]struct{}{block: {}}
+	toVisit := []*ssa.BasicBlock{block}
+	for len(toVisit) > 0 {
+		current := toVisit[len(toVisit)-1]
+		toVisit = toVisit[:len(toVisit)-1]
+		for _, succ := range current.Succs {
+			if succ == header {
+				return true
+			}
+			if _, ok := visited[succ]; ok || !header.Dominates(succ) {
+				continue
+			}
+			visited[succ] = struct{}{}
+			toVisit = append(toVisit, succ)
+		}
+	}
+	return false
+}
+
+// isFeasible returns whether the block may run after the predecessor.
+func (cfg *CFG) isFeasible(pred, block *ssa.BasicBlock) bool {
+	for _, succ := range cfg.succs[pred] {
+		if succ == block {
+			return true
+		}
+	}
+	return false
+}
+
+// calculateFunctionState computes the states of the blocks in the order they run, and then passes the state of the
+// function.
+func (cfg *CFG) calculateFunctionState(context *domain.Context, done continuation) {
+	cfg.calculateBlockStates(context, 0, func() {
+		resume(done, cfg.getFunctionState())
+	})
+}
+
+func (cfg *CFG) calculateBlockStates(context *domain.Context, i int, done func()) {
+	if i == len(cfg.blocks) {
+		schedule(done)
+		return
+	}
+	block := cfg.blocks[i]
+	header, isInLoop := cfg.loopHeaders[block.Index]
+	if isInLoop && header == block.Index {
+		cfg.loops[header] = &loop{cfg: cfg, header: header, entry: context.Copy()}
+	}
+	cfg.calculateBlockState(context, block, func() {
+		if !isInLoop || cfg.loopEnds[header] != block.Index {
+			cfg.calculateBlockStates(context, i+1, done)
+			return
+		}
+		// The iteration ended, so the goroutines it started are started again by the next iteration
+		cfg.loops[header].runInstances(func() {
+			cfg.calculateBlockStates(context, i+1, done)
+		})
+	})
+}
+
+func (cfg *CFG) calculateBlockState(context *domain.Context, block *ssa.BasicBlock, done func()) {
+	var blockLoop *loop
+	if header, ok := cfg.loopHeaders[block.Index]; ok {
+		blockLoop = cfg.loops[header]
+	}
+	getBlockSummary(context, blockLoop, block, func(blockState *domain.BlockState) {
+		cfg.ComputedBlocks[block.Index] = blockState
+		deferedFunctions := blockState.DeferredFunctions
+		if deferedFunctions.Len() == 0 {
+			schedule(done)
+			return
+		}
+		cfg.runDefers(context, deferedFunctions, func(deferState *domain.BlockState) {
+			cfg.ComputedDeferBlocks[block.Index] = deferState
+			schedule(done)
+		})
+	})
+}
+
+func (l *loop) runInstances(done func()) {
+	if len(l.instances) == 0 {
+		schedule(done)
+		return
+	}
+	instance := l.instances[0]
+	l.instances = l.instances[1:]
+	instance(func() {
+		l.runInstances(done)
+	})
+}
+
+// isIterationAllocation returns whether the allocation is made by the loop, so each iteration makes its own.
+func (l *loop) isIterationAllocation(alloc pointerAnalysis.Allocation) bool {
+	ins, ok := alloc.Site.(ssa.Instruction)
+	if !ok || ins.Block() == nil || ins.Parent() != l.cfg.blocks[0].Parent() {
+		return false
+	}
+	header, ok := l.cfg.loopHeaders[ins.Block().Index]
+	return ok && header == l.header
+}
 
-	// recursion
-	var branchState *domain.BlockState
-	for _, nextBlock := range block.Succs {
-		// if it's a cycle we skip it
-		if cfg.visitedBlocksStack.Contains(nextBlock.Index) {
+// getOtherIterationState returns the accesses of an instance of a goroutine started by another iteration of the loop,
+// without the accesses to objects allocated by the loop, since the iteration that started the instance allocated its
+// own objects.
+func (l *loop) getOtherIterationState(funcState *domain.BlockState) *domain.BlockState {
+	guardedAccesses := make([]*domain.GuardedAccess, 0, len(funcState.GuardedAccesses))
+	for _, guardedAccess := range funcState.GuardedAccesses {
+		allocs := GlobalPointerAnalysis.PointsTo(guardedAccess.Value)
+		isIterationAccess := len(allocs) > 0
+		for _, alloc := range allocs {
+			if !l.isIterationAllocation(alloc) {
+				isIterationAccess = false
+			}
+		}
+		if !isIterationAccess {
+			guardedAccesses = append(guardedAccesses, guardedAccess)
+		}
+	}
+	return domain.CreateBlockState(guardedAccesses, funcState.Lockset, funcState.DeferredFunctions)
+}
+
+// getFunctionState adds the locks held at the entry of each block to the accesses of the block, and runs the deferred
+// calls at the exits of the function.
+func (cfg *CFG) getFunctionState() *domain.BlockState {
+	entries, exits := cfg.solve()
+	funcState := domain.GetEmptyBlockState()
+	for _, block := range cfg.blocks {
+		for _, guardedAccess := range cfg.ComputedBlocks[block.Index].GuardedAccesses {
+			guardedAccess.Lockset.UpdateWithPrevLockset(entries[block.Index].lockset)
+		}
+		funcState.GuardedAccesses = append(funcState.GuardedAccesses, cfg.ComputedBlocks[block.Index].GuardedAccesses...)
+	}
+
+	var exitState *domain.BlockState
+	for _, block := range cfg.blocks {
+		if len(cfg.succs[block]) > 0 {
 			continue
 		}
+		state := cfg.runExitDefers(exits[block.Index])
+		if exitState == nil {
+			exitState = state
+		} else {
+			exitState.MergeSiblingBlock(state)
+		}
+	}
+	if exitState == nil { // The function never returns
+		return funcState
+	}
+	funcState.GuardedAccesses = append(funcState.GuardedAccesses, exitState.GuardedAccesses...)
+	funcState.Lockset = exitState.Lockset
+	return funcState
+}
 
-		retBlockState := cfg.CalculateFunctionState(context, nextBlock)
-		if branchState == nil {
-			branchState = retBlockState.Copy()
+// runExitDefers returns the state of the exit after running the deferred calls, from the last registered one. Calls
+// that were registered on some of the paths to the exit only may or may not run, so their state is merged as a sibling
+// of not running them.
+func (cfg *CFG) runExitDefers(exit *blockFact) *domain.BlockState {
+	state := domain.CreateBlockState(make([]*domain.GuardedAccess, 0), exit.lockset.Copy(), stacks.NewCallCommonStack())
+	for i := len(cfg.blocks) - 1; i >= 0; i-- {
+		deferState, ok := cfg.ComputedDeferBlocks[cfg.blocks[i].Index]
+		if !ok {
+			continue
+		}
+		if _, ok := exit.mayDefers[cfg.blocks[i].Index]; !ok {
+			continue
+		}
+		if _, ok := exit.mustDefers[cfg.blocks[i].Index]; ok {
+			state.MergeChildBlock(deferState.Copy())
+			continue
+		}
+		deferredState := state.Copy()
+		deferredState.MergeChildBlock(deferState.Copy())
+		state.MergeSiblingBlock(deferredState)
+	}
+	return state
+}
+
+// solve returns the facts at the entry and at the exit of each block. The facts of the blocks that weren't reached yet
+// are missing, and are skipped when the facts of the predecessors are merged.
+func (cfg *CFG) solve() (map[int]*blockFact, map[int]*blockFact) {
+	entries := make(map[int]*blockFact, len(cfg.blocks))
+	exits := make(map[int]*blockFact, len(cfg.blocks))
+	isQueued := make(map[int]bool, len(cfg.blocks))
+	worklist := make([]*ssa.BasicBlock, 0, len(cfg.blocks))
+	for _, block := range cfg.blocks {
+		worklist = append(worklist, block)
+		isQueued[block.Index] = true
+	}
+	for len(worklist) > 0 {
+		block := worklist[0]
+		worklist = worklist[1:]
+		isQueued[block.Index] = false
+
+		entry := cfg.getEntryFact(block, exits)
+		entries[block.Index] = entry
+		exit := cfg.transfer(block, entry)
+		if previous, ok := exits[block.Index]; ok && previous.equals(exit) {
+			continue
+		}
+		exits[block.Index] = exit
+		for _, succ := range cfg.succs[block] {
+			if !isQueued[succ.Index] {
+				worklist = append(worklist, succ)
+				isQueued[succ.Index] = true
+			}
+		}
+	}
+	return entries, exits
+}
+
+func (cfg *CFG) getEntryFact(block *ssa.BasicBlock, exits map[int]*blockFact) *blockFact {
+	var entry *blockFact
+	for _, pred := range block.Preds {
+		exit, ok := exits[pred.Index]
+		if !ok || !cfg.isFeasible(pred, block) {
+			continue
+		}
+		if entry == nil {
+			entry = exit.copy()
 		} else {
-			branchState.MergeSiblingBlock(retBlockState)
+			entry.merge(exit)
 		}
 	}
+	if entry == nil { // The entry of the function
+		entry = &blockFact{lockset: domain.NewLockset(), mayDefers: make(map[int]struct{}), mustDefers: make(map[int]struct{})}
+	}
+	return entry
+}
 
-	if branchState != nil {
-		blockState.MergeChildBlock(branchState)
+func (cfg *CFG) transfer(block *ssa.BasicBlock, entry *blockFact) *blockFact {
+	exit := entry.copy()
+	blockState := cfg.ComputedBlocks[block.Index]
+	exit.lockset.UpdateWithNewLockSet(blockState.Lockset.Locks, blockState.Lockset.Unlocks)
+	if _, ok := cfg.ComputedDeferBlocks[block.Index]; ok {
+		exit.mayDefers[block.Index] = struct{}{}
+		exit.mustDefers[block.Index] = struct{}{}
 	}
+	return exit
+}
 
-	// Defer
-	if deferState, ok := cfg.ComputedDeferBlocks[block.Index]; ok {
-		blockState.MergeChildBlock(deferState)
+func (fact *blockFact) copy() *blockFact {
+	newFact := &blockFact{
+		lockset:    fact.lockset.Copy(),
+		mayDefers:  make(map[int]struct{}, len(fact.mayDefers)),
+		mustDefers: make(map[int]struct{}, len(fact.mustDefers)),
+	}
+	for blockIndex := range fact.mayDefers {
+		newFact.mayDefers[blockIndex] = struct{}{}
 	}
-	return blockState
+	for blockIndex := range fact.mustDefers {
+		newFact.mustDefers[blockIndex] = struct{}{}
+	}
+	return newFact
 }
 
-func (cfg *CFG) calculateBlockState(context *domain.Context, block *ssa.BasicBlock) {
-	if _, ok := cfg.ComputedBlocks[block.Index]; !ok {
-		cfg.ComputedBlocks[block.Index] = GetBlockSummary(context, block)
-		deferedFunctions := cfg.ComputedBlocks[block.Index].DeferredFunctions
-		if deferedFunctions.Len() > 0 {
-			cfg.ComputedDeferBlocks[block.Index] = cfg.runDefers(context, deferedFunctions)
+func (fact *blockFact) merge(factToMerge *blockFact) {
+	fact.lockset.MergeSiblingLockset(factToMerge.lockset)
+	for blockIndex := range factToMerge.mayDefers {
+		fact.mayDefers[blockIndex] = struct{}{}
+	}
+	for blockIndex := range fact.mustDefers {
+		if _, ok := factToMerge.mustDefers[blockIndex]; !ok {
+			delete(fact.mustDefers, blockIndex)
+		}
+	}
+}
+
+// equals compares the mutexes of the facts, regardless of the calls that last locked or unlocked them.
+func (fact *blockFact) equals(other *blockFact) bool {
+	return isSameKeys(fact.lockset.Locks, other.lockset.Locks) && isSameKeys(fact.lockset.Unlocks, other.lockset.Unlocks) &&
+		len(fact.mayDefers) == len(other.mayDefers) && len(fact.mustDefers) == len(other.mustDefers)
+}
+
+func isSameKeys(locksA, locksB map[domain.LockID]*ssa.CallCommon) bool {
+	if len(locksA) != len(locksB) {
+		return false
+	}
+	for lockID := range locksA {
+		if _, ok := locksB[lockID]; !ok {
+			return false
 		}
 	}
+	return true
 }
@@ -0,0 +1,64 @@
+package ssaUtils
+
+import (
+	"github.com/pdufour/Chronos/domain"
+	"github.com/pdufour/Chronos/pointerAnalysis"
+	"go/types"
+	"golang.org/x/tools/go/ssa"
+)
+
+var channelsCache = make(map[pointerAnalysis.Allocation]*domain.Channel)
+
+func HandleSend(context *domain.Context, send *ssa.Send) {
+	event := newSyncEvent(context, true)
+	addChannelOperation(event, send.Chan, domain.SyncOpSend)
+	context.Acquire(event)
+}
+
+func HandleReceive(context *domain.Context, recv *ssa.UnOp) {
+	event := newSyncEvent(context, true)
+	addChannelOperation(event, recv.X, domain.SyncOpReceive)
+	context.Acquire(event)
+}
+
+func HandleClose(context *domain.Context, channel ssa.Value) {
+	event := newSyncEvent(context, true)
+	addChannelOperation(event, channel, domain.SyncOpClose)
+	context.Acquire(event)
+}
+
+// HandleSelect records all the cases of the select as a single event, since only one of them completes.
+func HandleSelect(context *domain.Context, sel *ssa.Select) {
+	event := newSyncEvent(context, sel.Blocking)
+	for _, state := range sel.States {
+		if state.Dir == types.SendOnly {
+			addChannelOperation(event, state.Chan, domain.SyncOpSend)
+		} else {
+			addChannelOperation(event, state.Chan, domain.SyncOpReceive)
+		}
+	}
+	context.Acquire(event)
+}
+
+func addChannelOperation(event *domain.SyncEvent, channel ssa.Value, kind domain.SyncOpKind) {
+	for _, alloc := range GetAllocations(channel) {
+		ch, ok := channelsCache[alloc]
+		if !ok {
+			ch = domain.NewChannel(getChannelCapacity(alloc))
+			channelsCache[alloc] = ch
+		}
+		event.AddOperation(ch, kind)
+	}
+}
+
+func getChannelCapacity(alloc pointerAnalysis.Allocation) int {
+	makeChan, ok := alloc.Site.(*ssa.MakeChan)
+	if !ok {
+		return -1
+	}
+	size, ok := makeChan.Size.(*ssa.Const)
+	if !ok {
+		return -1
+	}
+	return int(size.Int64())
+}
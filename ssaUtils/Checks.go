@@ -0,0 +1,118 @@
+package ssaUtils
+
+import (
+	"fmt"
+	"github.com/pdufour/Chronos/domain"
+	"github.com/pdufour/Chronos/pointerAnalysis"
+	"github.com/pdufour/Chronos/ssaPureUtils"
+	"golang.org/x/tools/go/ssa"
+	"strings"
+)
+
+// Check is an analysis of the accesses found by the traversal of the program.
+type Check string
+
+const (
+	// CheckRace reports accesses to the same location from goroutines that may run concurrently.
+	CheckRace Check = "race"
+	// CheckDeadlock reports mutexes that goroutines that may run concurrently acquire in different orders.
+	CheckDeadlock Check = "deadlock"
+)
+
+var checks = []Check{CheckRace, CheckDeadlock}
+
+// ParseChecks returns the checks by their comma separated names.
+func ParseChecks(list string) ([]Check, error) {
+	names := make([]string, 0, len(checks))
+	for _, check := range checks {
+		names = append(names, string(check))
+	}
+	parsed := make([]Check, 0)
+	for _, name := range SplitPatterns(list) {
+		found := false
+		for _, check := range checks {
+			if string(check) == name {
+				parsed = append(parsed, check)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown check %s. Please provide %s", name, strings.Join(names, ", "))
+		}
+	}
+	if len(parsed) == 0 {
+		return nil, fmt.Errorf("please provide at least one check of %s", strings.Join(names, ", "))
+	}
+	return parsed, nil
+}
+
+// Findings are the reports of the checks. The reports of the checks that didn't run are empty.
+type Findings struct {
+	Conflicts [][]*domain.GuardedAccess // Pairs of accesses that conflict with each other
+	Deadlocks [][]*domain.GuardedAccess // Cycles of acquisitions of mutexes, as returned by pointerAnalysis.FindDeadlocks
+}
+
+// CheckFunction traverses the program from the function, like AnalyzeFunction, and runs the checks on the accesses.
+func CheckFunction(entryFunc *ssa.Function, checksToRun []Check) *Findings {
+	return checkTraversal(func(context *domain.Context, done continuation) {
+		entryCallCommon := ssa.CallCommon{Value: entryFunc}
+		handleCallCommon(context, &entryCallCommon, entryFunc.Pos(), done)
+	}, checksToRun)
+}
+
+// CheckParallelTests traverses the program from the top level tests of a test binary that call t.Parallel, and runs
+// the checks on the accesses. The tests run one after the other up to their call to t.Parallel, and concurrently with
+// each other after it.
+func CheckParallelTests(tests []*ssa.Function, checksToRun []Check) *Findings {
+	return checkTraversal(func(context *domain.Context, done continuation) {
+		handleTests(context, tests, domain.GetEmptyBlockState(), done)
+	}, checksToRun)
+}
+
+// CheckEntryPoints runs the checks from each of the entry points. The parallel top level tests of a test binary are
+// checked together, since they run concurrently with each other.
+func CheckEntryPoints(entryPoints []EntryPoint, checksToRun []Check) *Findings {
+	findings := &Findings{Conflicts: make([][]*domain.GuardedAccess, 0), Deadlocks: make([][]*domain.GuardedAccess, 0)}
+	addFindings := func(entryFindings *Findings) {
+		findings.Conflicts = append(findings.Conflicts, entryFindings.Conflicts...)
+		findings.Deadlocks = append(findings.Deadlocks, entryFindings.Deadlocks...)
+	}
+	mainPkgs := make([]*ssa.Package, 0)
+	parallelTests := make(map[*ssa.Package][]*ssa.Function)
+	for _, entryPoint := range entryPoints {
+		if !ssaPureUtils.IsTestRoot(entryPoint.Func) || !ssaPureUtils.CallsTestParallel(entryPoint.Func) {
+			addFindings(CheckFunction(entryPoint.Func, checksToRun))
+			continue
+		}
+		if _, ok := parallelTests[entryPoint.Main]; !ok {
+			mainPkgs = append(mainPkgs, entryPoint.Main)
+		}
+		parallelTests[entryPoint.Main] = append(parallelTests[entryPoint.Main], entryPoint.Func)
+	}
+	for _, mainPkg := range mainPkgs {
+		addFindings(CheckParallelTests(parallelTests[mainPkg], checksToRun))
+	}
+	return findings
+}
+
+// checkTraversal runs the traversal from a new flow, which runs as a top level test, and runs the checks on the
+// accesses.
+func checkTraversal(handle func(context *domain.Context, done continuation), checksToRun []Check) *Findings {
+	resetTraversalState()
+	context := domain.NewEmptyContext()
+	context.Test = domain.NewTest(nil)
+	functionState := runTraversal(func(done continuation) {
+		handle(context, done)
+	})
+	completeTest(context, context.Test)
+	findings := &Findings{Conflicts: make([][]*domain.GuardedAccess, 0), Deadlocks: make([][]*domain.GuardedAccess, 0)}
+	for _, check := range checksToRun {
+		switch check {
+		case CheckRace:
+			findings.Conflicts = pointerAnalysis.Analysis(GlobalPointerAnalysis, functionState.GuardedAccesses)
+		case CheckDeadlock:
+			findings.Deadlocks = pointerAnalysis.FindDeadlocks(functionState.GuardedAccesses)
+		}
+	}
+	return findings
+}
@@ -0,0 +1,156 @@
+package ssaUtils
+
+import (
+	"fmt"
+	"go/types"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/callgraph/rta"
+	"golang.org/x/tools/go/callgraph/vta"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+	"strings"
+)
+
+// Dispatch is the algorithm that chooses the implementations called by an interface method call.
+type Dispatch string
+
+const (
+	// DispatchTypes calls the method of every runtime type of the program that implements the interface.
+	DispatchTypes Dispatch = "types"
+	// DispatchCHA calls the method of every type of the program that implements the interface.
+	DispatchCHA Dispatch = "cha"
+	// DispatchRTA calls the methods of the types converted to interfaces in the functions reachable from the main
+	// functions.
+	DispatchRTA Dispatch = "rta"
+	// DispatchVTA calls the methods of the types whose values flow to the receiver of the call.
+	DispatchVTA Dispatch = "vta"
+)
+
+var dispatches = []Dispatch{DispatchTypes, DispatchCHA, DispatchRTA, DispatchVTA}
+
+// GlobalDispatch is the algorithm used by the analysis. It's read by InitPreProcess, so it must be set before.
+var GlobalDispatch = DispatchTypes
+
+// dispatchCache holds the callees of the interface method calls, when the call graph of the program was built.
+var dispatchCache map[*ssa.CallCommon][]*ssa.Function
+
+// ParseDispatch returns the algorithm by its name.
+func ParseDispatch(name string) (Dispatch, error) {
+	names := make([]string, 0, len(dispatches))
+	for _, dispatch := range dispatches {
+		if string(dispatch) == name {
+			return dispatch, nil
+		}
+		names = append(names, string(dispatch))
+	}
+	return "", fmt.Errorf("unknown dispatch %s. Please provide %s", name, strings.Join(names, ", "))
+}
+
+// initDispatch builds the call graph of the program with the algorithm, and caches the callees of each interface method
+// call in it.
+func initDispatch(prog *ssa.Program, dispatch Dispatch) {
+	dispatchCache = nil
+	typesCache = make(map[methodKey][]*ssa.Function)
+	var graph *callgraph.Graph
+	switch dispatch {
+	case DispatchCHA:
+		graph = cha.CallGraph(prog)
+	case DispatchRTA:
+		graph = getRTACallGraph(prog)
+	case DispatchVTA:
+		graph = vta.CallGraph(ssautil.AllFunctions(prog), cha.CallGraph(prog))
+	default:
+		return
+	}
+
+	dispatchCache = make(map[*ssa.CallCommon][]*ssa.Function)
+	seen := make(map[dispatchEdge]struct{})
+	for _, node := range graph.Nodes {
+		for _, edge := range node.Out {
+			if edge.Site == nil || !edge.Site.Common().IsInvoke() {
+				continue
+			}
+			callee := getWrappedMethod(edge.Callee.Func)
+			if !isImplementation(callee) {
+				continue
+			}
+			call := edge.Site.Common()
+			key := dispatchEdge{call: call, callee: callee}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			dispatchCache[call] = append(dispatchCache[call], callee)
+		}
+	}
+	for call, callees := range dispatchCache {
+		dispatchCache[call] = sortMethodImplementations(callees)
+	}
+}
+
+// dispatchEdge is an implementation an interface method call may call.
+type dispatchEdge struct {
+	call   *ssa.CallCommon
+	callee *ssa.Function
+}
+
+// getWrappedMethod returns the method the wrapper calls, if the function is the wrapper of a method in the method set
+// of another type, like the pointer type of a value receiver or a type that embeds the receiver. The call graph may only
+// have the edge to the wrapper. Wrappers of methods of embedded interfaces call no method statically, so they're kept.
+func getWrappedMethod(fn *ssa.Function) *ssa.Function {
+	for fn != nil && strings.HasPrefix(fn.Synthetic, "wrapper for ") {
+		var wrapped *ssa.Function
+		for _, block := range fn.Blocks {
+			for _, ins := range block.Instrs {
+				if call, ok := ins.(*ssa.Call); ok && call.Call.StaticCallee() != nil {
+					wrapped = call.Call.StaticCallee()
+				}
+			}
+		}
+		if wrapped == nil {
+			return fn
+		}
+		fn = wrapped
+	}
+	return fn
+}
+
+// getRTACallGraph returns the call graph of the functions reachable from the main and init functions of the program.
+func getRTACallGraph(prog *ssa.Program) *callgraph.Graph {
+	roots := make([]*ssa.Function, 0)
+	for _, pkg := range prog.AllPackages() {
+		if pkg.Func("main") == nil {
+			continue
+		}
+		roots = append(roots, pkg.Func("main"))
+		if initFunc := pkg.Func("init"); initFunc != nil {
+			roots = append(roots, initFunc)
+		}
+	}
+	if len(roots) == 0 {
+		return callgraph.New(nil)
+	}
+	return rta.Analyze(roots, true).CallGraph
+}
+
+// GetInvokeCallees returns the implementations the interface method call may call, sorted by their position.
+func GetInvokeCallees(callCommon *ssa.CallCommon) []*ssa.Function {
+	if dispatchCache == nil {
+		return GetMethodImplementations(callCommon.Value.Type().Underlying(), callCommon.Method)
+	}
+	return dispatchCache[callCommon]
+}
+
+// isImplementation returns whether the method has a body of its own. Methods of instantiated generic types are
+// synthetic instances, but have a body too.
+func isImplementation(fn *ssa.Function) bool {
+	return fn != nil && (fn.Synthetic == "" || fn.Origin() != nil)
+}
+
+// methodKey identifies a method of an interface. Methods of embedded interfaces are shared by the interfaces embedding
+// them.
+type methodKey struct {
+	recv   *types.Interface
+	method *types.Func
+}
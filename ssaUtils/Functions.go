@@ -5,73 +5,120 @@ import (
 	"github.com/pdufour/Chronos/ssaPureUtils"
 	"github.com/pdufour/Chronos/utils/stacks"
 	"go/token"
-	"go/types"
 	"golang.org/x/tools/go/ssa"
-	"strings"
 )
 
-var functionsCache = make(map[*types.Signature]*domain.FunctionState)
+// VisitedFunctions counts the functions traversed by the analysis, including repeated visits of the same function.
+var VisitedFunctions int
 
+// HandleCallCommon returns the state of the call, traversing the functions it may call.
 func HandleCallCommon(context *domain.Context, callCommon *ssa.CallCommon, pos token.Pos) *domain.BlockState {
-	funcState := domain.GetEmptyBlockState()
+	return runTraversal(func(done continuation) {
+		handleCallCommon(context, callCommon, pos, done)
+	})
+}
 
+func handleCallCommon(context *domain.Context, callCommon *ssa.CallCommon, pos token.Pos, done continuation) {
 	// if we already visited this path, it means we're (probably) in a recursion so we return to avoid infinite loop
 	if context.StackTrace.Contains(int(pos)) {
-		return funcState
+		resume(done, domain.GetEmptyBlockState())
+		return
 	}
 
 	context.StackTrace.Push(int(pos))
-	defer context.StackTrace.Pop()
+	ret := func(funcState *domain.BlockState) {
+		context.StackTrace.Pop()
+		resume(done, funcState)
+	}
 
 	if callCommon.IsInvoke() {
-		impls := GetMethodImplementations(callCommon.Value.Type().Underlying(), callCommon.Method)
-		if len(impls) > 0 {
-			funcState = HandleFunction(context, impls[0])
-			for _, impl := range impls[1:] {
-				funcstateRet := HandleFunction(context, impl)
-				funcState.MergeSiblingBlock(funcstateRet)
-			}
-		}
-		return funcState
+		impls := GetInvokeCallees(callCommon)
+		handleSiblings(len(impls), func(i int, done continuation) {
+			handleFunctionCall(context, callCommon, impls[i], done)
+		}, ret)
+		return
+	}
+
+	if onceFuncCall := ssaPureUtils.GetOnceFuncCall(callCommon.Value); onceFuncCall != nil {
+		handleOnceFunc(context, onceFuncCall, ret)
+		return
 	}
 
+	funcState := domain.GetEmptyBlockState()
 	switch call := callCommon.Value.(type) {
 	case *ssa.Builtin:
 		HandleBuiltin(funcState, context, callCommon)
-		return funcState
+		resume(ret, funcState)
+		return
 	case *ssa.MakeClosure:
 		fn := callCommon.Value.(*ssa.MakeClosure).Fn.(*ssa.Function)
-		funcStateRet := HandleFunction(context, fn)
-		return funcStateRet
+		handleFunctionCall(context, callCommon, fn, ret)
+		return
 	case *ssa.Function:
-		if ssaPureUtils.IsLock(call) {
-			AddLock(funcState, callCommon, false)
-			return funcState
-		}
-		if ssaPureUtils.IsUnlock(call) {
-			AddLock(funcState, callCommon, true)
-			return funcState
-		}
-
-		var blockStateRet *domain.BlockState
-		sig := callCommon.Signature()
-		if cachedFunctionState, ok := functionsCache[sig]; ok {
-			copiedState := cachedFunctionState.Copy() // Copy to avoid override cached item
-			copiedState.AddContextToFunction(context)
-			blockStateRet = domain.CreateBlockState(copiedState.GuardedAccesses, copiedState.Lockset, stacks.NewCallCommonStack())
-		} else {
-			blockStateRet = HandleFunction(context, call)
-			fs := domain.CreateFunctionState(blockStateRet.GuardedAccesses, blockStateRet.Lockset)
-			fs.RemoveContextFromFunction()
-			functionsCache[sig] = fs
-		}
-		return blockStateRet
-
+		handleCallee(context, callCommon, call, ret)
+		return
 	case ssa.Instruction:
 		HandleInstruction(funcState, context, call)
-		return funcState
 	}
-	return funcState
+	// The called value is a function value, like a parameter, a field of a struct or a closure returned by a function
+	handleDynamicCall(context, callCommon, func(dynamicState *domain.BlockState) {
+		funcState.MergeChildBlock(dynamicState)
+		resume(ret, funcState)
+	})
+}
+
+// handleCallee passes the state of the call to the function. Calls to the synchronization primitives update the state
+// of the synchronization instead of being traversed.
+func handleCallee(context *domain.Context, callCommon *ssa.CallCommon, call *ssa.Function, done continuation) {
+	funcState := domain.GetEmptyBlockState()
+	switch {
+	case ssaPureUtils.IsBoundMethodWrapper(call): // The receiver isn't an argument of the call, so the wrapper is traversed
+		handleFunctionCall(context, callCommon, call, done)
+		return
+	case ssaPureUtils.IsLock(call):
+		addLockAcquisition(funcState, context, callCommon, false)
+		AddLock(funcState, callCommon, false)
+	case ssaPureUtils.IsUnlock(call):
+		AddLock(funcState, callCommon, true)
+	case ssaPureUtils.IsRLock(call):
+		addLockAcquisition(funcState, context, callCommon, true)
+		AddRLock(funcState, callCommon, false)
+	case ssaPureUtils.IsRUnlock(call):
+		AddRLock(funcState, callCommon, true)
+	case ssaPureUtils.IsAtomic(call):
+		HandleAtomic(funcState, context, callCommon, call)
+	case ssaPureUtils.IsOnceDo(call):
+		handleOnceDo(context, callCommon, done)
+		return
+	case ssaPureUtils.IsTestRun(call):
+		handleTestRun(context, callCommon, done)
+		return
+	case ssaPureUtils.IsTestParallel(call):
+		handleTestParallel(context)
+	case ssaPureUtils.IsRunParallel(call):
+		handleRunParallel(context, callCommon, done)
+		return
+	case ssaPureUtils.IsFuzz(call):
+		handleFuzz(context, callCommon, done)
+		return
+	case ssaPureUtils.IsWaitGroupDone(call):
+		HandleWaitGroupDone(context, callCommon)
+	case ssaPureUtils.IsWaitGroupWait(call):
+		HandleWaitGroupWait(context, callCommon)
+	default:
+		handleFunctionCall(context, callCommon, call, done)
+		return
+	}
+	resume(done, funcState)
+}
+
+// handleDynamicCall passes the state of the call of a function value. The functions the value may point to are
+// merged as siblings, the same way implementations of an interface method are.
+func handleDynamicCall(context *domain.Context, callCommon *ssa.CallCommon, done continuation) {
+	callees := GlobalPointerAnalysis.Callees(callCommon)
+	handleSiblings(len(callees), func(i int, done continuation) {
+		handleCallee(context, callCommon, callees[i], done)
+	}, done)
 }
 
 func HandleBuiltin(functionState *domain.BlockState, context *domain.Context, call *ssa.CallCommon) {
@@ -79,126 +126,194 @@ func HandleBuiltin(functionState *domain.BlockState, context *domain.Context, ca
 	args := call.Args
 	switch name := callCommon.Name(); name {
 	case "delete":
-		functionState.GuardedAccesses = append(functionState.GuardedAccesses, domain.AddGuardedAccess(call.Pos(), args[0], domain.GuardAccessWrite, functionState.Lockset, context))
+		addGuardedAccess(functionState, call.Pos(), args[0], domain.GuardAccessWrite, context)
 	case "cap", "len":
-		functionState.GuardedAccesses = append(functionState.GuardedAccesses, domain.AddGuardedAccess(call.Pos(), args[0], domain.GuardAccessRead, functionState.Lockset, context))
+		addGuardedAccess(functionState, call.Pos(), args[0], domain.GuardAccessRead, context)
 	case "append":
-		functionState.GuardedAccesses = append(functionState.GuardedAccesses, domain.AddGuardedAccess(call.Pos(), args[1], domain.GuardAccessRead, functionState.Lockset, context))
-		functionState.GuardedAccesses = append(functionState.GuardedAccesses, domain.AddGuardedAccess(call.Pos(), args[0], domain.GuardAccessWrite, functionState.Lockset, context))
+		addGuardedAccess(functionState, call.Pos(), args[1], domain.GuardAccessRead, context)
+		addGuardedAccess(functionState, call.Pos(), args[0], domain.GuardAccessWrite, context)
+	case "close":
+		HandleClose(context, args[0])
 	case "copy":
-		functionState.GuardedAccesses = append(functionState.GuardedAccesses, domain.AddGuardedAccess(call.Pos(), args[0], domain.GuardAccessRead, functionState.Lockset, context))
-		functionState.GuardedAccesses = append(functionState.GuardedAccesses, domain.AddGuardedAccess(call.Pos(), args[1], domain.GuardAccessWrite, functionState.Lockset, context))
+		addGuardedAccess(functionState, call.Pos(), args[0], domain.GuardAccessRead, context)
+		addGuardedAccess(functionState, call.Pos(), args[1], domain.GuardAccessWrite, context)
+	}
+}
+
+// addGuardedAccess adds the access to the state, unless the value accesses memory that no other goroutine may reach.
+func addGuardedAccess(functionState *domain.BlockState, pos token.Pos, value ssa.Value, kind domain.OpKind, context *domain.Context) *domain.GuardedAccess {
+	if !GlobalPointerAnalysis.IsShared(value) {
+		return nil
+	}
+	guardedAccess := domain.AddGuardedAccess(pos, value, kind, functionState.Lockset, context)
+	functionState.GuardedAccesses = append(functionState.GuardedAccesses, guardedAccess)
+	return guardedAccess
+}
+
+// addElementAccess adds the access to the element at the index of the array or the slice.
+func addElementAccess(functionState *domain.BlockState, pos token.Pos, value, index ssa.Value, kind domain.OpKind, context *domain.Context) {
+	if guardedAccess := addGuardedAccess(functionState, pos, value, kind, context); guardedAccess != nil {
+		guardedAccess.Index = index
+		guardedAccess.Path = guardedAccess.Path.Element()
 	}
 }
 
 func HandleInstruction(functionState *domain.BlockState, context *domain.Context, ins ssa.Instruction) {
 	switch call := ins.(type) {
 	case *ssa.UnOp:
-		guardedAccess := domain.AddGuardedAccess(call.Pos(), call.X, domain.GuardAccessRead, functionState.Lockset, context)
-		functionState.GuardedAccesses = append(functionState.GuardedAccesses, guardedAccess)
+		addGuardedAccess(functionState, call.Pos(), call.X, domain.GuardAccessRead, context)
+		if ssaPureUtils.IsReceive(call) {
+			HandleReceive(context, call)
+		}
 	case *ssa.Field:
-		guardedAccess := domain.AddGuardedAccess(call.Pos(), call, domain.GuardAccessRead, functionState.Lockset, context)
-		functionState.GuardedAccesses = append(functionState.GuardedAccesses, guardedAccess)
+		addGuardedAccess(functionState, call.Pos(), call, domain.GuardAccessRead, context)
 	case *ssa.FieldAddr:
-		guardedAccess := domain.AddGuardedAccess(call.Pos(), call, domain.GuardAccessRead, functionState.Lockset, context)
-		functionState.GuardedAccesses = append(functionState.GuardedAccesses, guardedAccess)
+		// The access is recorded by the atomic operations using the address
+		if ssaPureUtils.IsAtomicOperand(call) {
+			return
+		}
+		if ssaPureUtils.IsAddressOperand(call) {
+			return
+		}
+		addGuardedAccess(functionState, call.Pos(), call, domain.GuardAccessRead, context)
 	case *ssa.Index:
-		guardedAccess := domain.AddGuardedAccess(call.Pos(), call.X, domain.GuardAccessRead, functionState.Lockset, context)
-		functionState.GuardedAccesses = append(functionState.GuardedAccesses, guardedAccess)
+		addElementAccess(functionState, call.Pos(), call.X, call.Index, domain.GuardAccessRead, context)
 	case *ssa.IndexAddr:
-		guardedAccess := domain.AddGuardedAccess(call.Pos(), call.X, domain.GuardAccessRead, functionState.Lockset, context)
-		functionState.GuardedAccesses = append(functionState.GuardedAccesses, guardedAccess)
+		if ssaPureUtils.IsAddressOperand(call) {
+			return
+		}
+		addElementAccess(functionState, call.Pos(), call.X, call.Index, domain.GuardAccessRead, context)
 	case *ssa.Lookup:
-		guardedAccess := domain.AddGuardedAccess(call.Pos(), call.X, domain.GuardAccessRead, functionState.Lockset, context)
-		functionState.GuardedAccesses = append(functionState.GuardedAccesses, guardedAccess)
+		addGuardedAccess(functionState, call.Pos(), call.X, domain.GuardAccessRead, context)
 	case *ssa.Panic:
-		guardedAccess := domain.AddGuardedAccess(call.Pos(), call.X, domain.GuardAccessRead, functionState.Lockset, context)
-		functionState.GuardedAccesses = append(functionState.GuardedAccesses, guardedAccess)
+		addGuardedAccess(functionState, call.Pos(), call.X, domain.GuardAccessRead, context)
 	case *ssa.Range:
-		guardedAccess := domain.AddGuardedAccess(call.Pos(), call.X, domain.GuardAccessRead, functionState.Lockset, context)
-		functionState.GuardedAccesses = append(functionState.GuardedAccesses, guardedAccess)
+		addGuardedAccess(functionState, call.Pos(), call.X, domain.GuardAccessRead, context)
 	case *ssa.TypeAssert:
-		guardedAccess := domain.AddGuardedAccess(call.Pos(), call.X, domain.GuardAccessRead, functionState.Lockset, context)
-		functionState.GuardedAccesses = append(functionState.GuardedAccesses, guardedAccess)
+		addGuardedAccess(functionState, call.Pos(), call.X, domain.GuardAccessRead, context)
 	case *ssa.BinOp:
-		guardedAccess := domain.AddGuardedAccess(call.Pos(), call.X, domain.GuardAccessRead, functionState.Lockset, context)
-		functionState.GuardedAccesses = append(functionState.GuardedAccesses, guardedAccess)
-		guardedAccess = domain.AddGuardedAccess(call.Pos(), call.Y, domain.GuardAccessRead, functionState.Lockset, context)
-		functionState.GuardedAccesses = append(functionState.GuardedAccesses, guardedAccess)
+		addGuardedAccess(functionState, call.Pos(), call.X, domain.GuardAccessRead, context)
+		addGuardedAccess(functionState, call.Pos(), call.Y, domain.GuardAccessRead, context)
 	case *ssa.If:
-		guardedAccess := domain.AddGuardedAccess(call.Pos(), call.Cond, domain.GuardAccessRead, functionState.Lockset, context)
-		functionState.GuardedAccesses = append(functionState.GuardedAccesses, guardedAccess)
+		addGuardedAccess(functionState, call.Pos(), call.Cond, domain.GuardAccessRead, context)
 	case *ssa.MapUpdate:
-		guardedAccess := domain.AddGuardedAccess(call.Pos(), call.Map, domain.GuardAccessWrite, functionState.Lockset, context)
-		functionState.GuardedAccesses = append(functionState.GuardedAccesses, guardedAccess)
-		guardedAccess = domain.AddGuardedAccess(call.Pos(), call.Value, domain.GuardAccessRead, functionState.Lockset, context)
-		functionState.GuardedAccesses = append(functionState.GuardedAccesses, guardedAccess)
+		addGuardedAccess(functionState, call.Pos(), call.Map, domain.GuardAccessWrite, context)
+		addGuardedAccess(functionState, call.Pos(), call.Value, domain.GuardAccessRead, context)
 	case *ssa.Store:
-		guardedAccess := domain.AddGuardedAccess(call.Pos(), call.Val, domain.GuardAccessRead, functionState.Lockset, context)
-		functionState.GuardedAccesses = append(functionState.GuardedAccesses, guardedAccess)
-		guardedAccess = domain.AddGuardedAccess(call.Pos(), call.Addr, domain.GuardAccessWrite, functionState.Lockset, context)
-		functionState.GuardedAccesses = append(functionState.GuardedAccesses, guardedAccess)
+		addGuardedAccess(functionState, call.Pos(), call.Val, domain.GuardAccessRead, context)
+		addGuardedAccess(functionState, call.Pos(), call.Addr, domain.GuardAccessWrite, context)
 	case *ssa.Return:
 		for _, retValue := range call.Results {
-			guardedAccess := domain.AddGuardedAccess(call.Pos(), retValue, domain.GuardAccessRead, functionState.Lockset, context)
-			functionState.GuardedAccesses = append(functionState.GuardedAccesses, guardedAccess)
+			addGuardedAccess(functionState, call.Pos(), retValue, domain.GuardAccessRead, context)
 		}
 	}
 }
 
-func GetBlockSummary(context *domain.Context, block *ssa.BasicBlock) *domain.BlockState {
-	funcState := domain.GetEmptyBlockState()
-	for _, ins := range block.Instrs {
+// getBlockSummary passes the state of the instructions of the block. The instructions after a call are handled once the
+// state of the call is known. blockLoop is the loop the block is in, or nil if it isn't in a loop.
+func getBlockSummary(context *domain.Context, blockLoop *loop, block *ssa.BasicBlock, done continuation) {
+	handleInstructions(domain.GetEmptyBlockState(), context, blockLoop, block.Instrs, done)
+}
+
+func handleInstructions(funcState *domain.BlockState, context *domain.Context, blockLoop *loop, instrs []ssa.Instruction, done continuation) {
+	for i, ins := range instrs {
+		rest := instrs[i+1:]
 		switch call := ins.(type) {
 		case *ssa.Call:
 			callCommon := call.Common()
-			funcStateRet := HandleCallCommon(context, callCommon, callCommon.Pos())
-			funcState.AddFunctionCallState(funcStateRet, true)
+			handleCallCommon(context, callCommon, callCommon.Pos(), func(funcStateRet *domain.BlockState) {
+				funcState.AddFunctionCallState(funcStateRet, true)
+				handleInstructions(funcState, context, blockLoop, rest, done)
+			})
+			return
 		case *ssa.Go:
 			callCommon := call.Common()
 			newState := domain.NewGoroutineExecutionState(context)
-			funcStateRet := HandleCallCommon(newState, callCommon, callCommon.Pos())
-			funcState.AddFunctionCallState(funcStateRet, false)
+			if blockLoop != nil {
+				newState.Iteration = getLoopIteration(call)
+				blockLoop.instances = append(blockLoop.instances, func(instanceDone func()) {
+					// The goroutines started by the previous iterations may still run, so another instance of the
+					// goroutine runs concurrently with the loop, after what the iteration synchronized with
+					loopState := domain.NewLoopGoroutineExecutionState(context, blockLoop.entry)
+					loopState.Iteration = newState.Iteration
+					handleCallCommon(loopState, callCommon, callCommon.Pos(), func(funcStateRet *domain.BlockState) {
+						funcState.AddFunctionCallState(blockLoop.getOtherIterationState(funcStateRet), false)
+						schedule(instanceDone)
+					})
+				})
+			}
+			handleCallCommon(newState, callCommon, callCommon.Pos(), func(funcStateRet *domain.BlockState) {
+				funcState.AddFunctionCallState(funcStateRet, false)
+				handleInstructions(funcState, context, blockLoop, rest, done)
+			})
+			return
 		case *ssa.Defer:
 			callCommon := call.Common()
 			funcState.DeferredFunctions.Push(callCommon)
+		case *ssa.Send:
+			HandleSend(context, call)
+		case *ssa.Select:
+			HandleSelect(context, call)
 		default:
 			HandleInstruction(funcState, context, ins)
 		}
 	}
-	return funcState
+	resume(done, funcState)
 }
 
-func (cfg *CFG) runDefers(context *domain.Context, defers *stacks.CallCommonStack) *domain.BlockState {
-	calculatedState := domain.GetEmptyBlockState()
-	defersItems := defers.GetItems()
-	for i := len(defersItems) - 1; i >= 0; i-- {
-		deferFunction := defersItems[i]
-		if deferFunction == nil {
-			break
-		}
-		retState := HandleCallCommon(context, deferFunction, deferFunction.Pos())
-		calculatedState.MergeChildBlock(retState)
-	}
-	return calculatedState
+func (cfg *CFG) runDefers(context *domain.Context, defers *stacks.CallCommonStack, done continuation) {
+	runDeferredCalls(context, defers.GetItems(), domain.GetEmptyBlockState(), done)
+}
 
+// runDeferredCalls runs the deferred calls from the last one to the first one.
+func runDeferredCalls(context *domain.Context, defersItems []*ssa.CallCommon, calculatedState *domain.BlockState, done continuation) {
+	i := len(defersItems) - 1
+	if i < 0 || defersItems[i] == nil {
+		resume(done, calculatedState)
+		return
+	}
+	deferFunction := defersItems[i]
+	handleCallCommon(context, deferFunction, deferFunction.Pos(), func(retState *domain.BlockState) {
+		calculatedState.MergeChildBlock(retState)
+		runDeferredCalls(context, defersItems[:i], calculatedState, done)
+	})
 }
 
+// HandleFunction returns the state of the function, traversing the functions it calls.
 func HandleFunction(context *domain.Context, fn *ssa.Function) *domain.BlockState {
-	funcState := domain.GetEmptyBlockState()
-	if fn.Pkg == nil {
-		return funcState
-	}
-	pkgName := fn.Pkg.Pkg.Path() // Used to guard against entering standard library packages
-	if !strings.Contains(pkgName, GlobalModuleName) {
-		return funcState
+	return runTraversal(func(done continuation) {
+		handleFunction(context, fn, done)
+	})
+}
+
+func handleFunction(context *domain.Context, fn *ssa.Function, done continuation) {
+	if !isModuleFunction(fn) {
+		resume(done, domain.GetEmptyBlockState())
+		return
 	}
 
 	// regular
 	if fn.Blocks == nil { // External function
-		return funcState
+		resume(done, domain.GetEmptyBlockState())
+		return
+	}
+	VisitedFunctions++
+	cfg := newCFG(fn)
+	schedule(func() {
+		cfg.calculateFunctionState(context, done)
+	})
+}
+
+func isModuleFunction(fn *ssa.Function) bool {
+	if ssaPureUtils.IsBoundMethodWrapper(fn) { // The wrapper only calls the method, which is checked when it's called
+		return true
+	}
+	pkg := fn.Pkg
+	if origin := fn.Origin(); pkg == nil && origin != nil { // Instances of generic functions belong to no package
+		pkg = origin.Pkg
+	}
+	if pkg == nil {
+		return false
 	}
-	cfg := newCFG()
-	calculatedState := cfg.CalculateFunctionState(context, fn.Blocks[0])
-	return calculatedState
+	return GlobalScope.ContainsPackage(pkg.Pkg) // Used to guard against entering standard library and dependencies packages
 }
@@ -1,6 +1,10 @@
 package ssaUtils
 
 import (
+	"fmt"
+	"go/types"
+	"runtime/debug"
+	"strings"
 	"testing"
 
 	"github.com/pdufour/Chronos/domain"
@@ -308,6 +312,87 @@ func Test_HandleFunction_WhileLoopWithoutHeader(t *testing.T) {
 	assert.True(t, stateA.MayConcurrent(stateB))
 }
 
+func Test_HandleFunction_LockCarriedAroundLoop(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/ForLoops/LockCarriedAroundLoop/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	assert.Len(t, state.Lockset.Locks, 0)
+
+	foundGA := FindGAWithFail(t, state.GuardedAccesses, func(ga *domain.GuardedAccess) bool {
+		if !IsGARead(ga) {
+			return false
+		}
+		val, ok := ga.Value.(*ssa.Const)
+		if !ok {
+			return false
+		}
+		return GetConstString(val) == "a"
+	})
+	assert.Len(t, foundGA.Lockset.Locks, 1)
+	assert.Len(t, foundGA.Lockset.Unlocks, 0)
+
+	foundGA = FindGAWithFail(t, state.GuardedAccesses, func(ga *domain.GuardedAccess) bool {
+		if !IsGARead(ga) {
+			return false
+		}
+		val, ok := ga.Value.(*ssa.Const)
+		if !ok {
+			return false
+		}
+		return GetConstString(val) == "b"
+	})
+	assert.Len(t, foundGA.Lockset.Locks, 0)
+	assert.Len(t, foundGA.Lockset.Unlocks, 1)
+}
+
+func Test_HandleFunction_UnlockAtEndOfLoop(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/ForLoops/UnlockAtEndOfLoop/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	assert.Len(t, state.Lockset.Locks, 0)
+	assert.Len(t, state.Lockset.Unlocks, 1)
+
+	foundGA := FindGAWithFail(t, state.GuardedAccesses, func(ga *domain.GuardedAccess) bool {
+		if !IsGARead(ga) {
+			return false
+		}
+		val, ok := ga.Value.(*ssa.Const)
+		if !ok {
+			return false
+		}
+		return GetConstString(val) == "a"
+	})
+	assert.Len(t, foundGA.Lockset.Locks, 0)
+	assert.Len(t, foundGA.Lockset.Unlocks, 1)
+}
+
+func Test_HandleFunction_GoroutineInLoop(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/ForLoops/GoroutineInLoop/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	assert.Len(t, filteredAccesses, 1)
+}
+
+func Test_HandleFunction_GoroutineAllocatedInLoop(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/ForLoops/GoroutineAllocatedInLoop/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	assert.Len(t, filteredAccesses, 0)
+}
+
+func Test_HandleFunction_GoroutineWaitedInLoop(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/ForLoops/GoroutineWaitedInLoop/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	assert.Len(t, filteredAccesses, 0)
+}
+
 func Test_HandleFunction_DataRaceIceCreamMaker(t *testing.T) {
 	f, _ := LoadMain(t, "./testdata/Functions/Interfaces/DataRaceIceCreamMaker/prog1.go")
 	ctx := domain.NewEmptyContext()
@@ -657,11 +742,10 @@ func Test_HandleFunction_NestedLockInStruct(t *testing.T) {
 }
 
 func Test_HandleFunction_DataRaceGoto(t *testing.T) {
-	f, pkg := LoadMain(t, "./testdata/Functions/General/DataRaceGoto/prog1.go")
+	f, _ := LoadMain(t, "./testdata/Functions/General/DataRaceGoto/prog1.go")
 	ctx := domain.NewEmptyContext()
 	state := HandleFunction(ctx, f)
-	conflictingAccesses, err := pointerAnalysis.Analysis(pkg, state.GuardedAccesses)
-	require.NoError(t, err)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
 	gas := FindMultipleGAWithFail(t, state.GuardedAccesses, func(ga *domain.GuardedAccess) bool {
 		if !IsGAWrite(ga) {
 			return false
@@ -708,11 +792,10 @@ func Test_HandleFunction_DataRaceGoto(t *testing.T) {
 }
 
 func Test_HandleFunction_DataRaceMap(t *testing.T) {
-	f, pkg := LoadMain(t, "./testdata/Functions/General/DataRaceMap/prog1.go")
+	f, _ := LoadMain(t, "./testdata/Functions/General/DataRaceMap/prog1.go")
 	ctx := domain.NewEmptyContext()
 	state := HandleFunction(ctx, f)
-	conflictingAccesses, err := pointerAnalysis.Analysis(pkg, state.GuardedAccesses)
-	require.NoError(t, err)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
 	gaA := FindGAWithFail(t, state.GuardedAccesses, func(ga *domain.GuardedAccess) bool {
 		if !IsGAWrite(ga) {
 			return false
@@ -759,11 +842,10 @@ func Test_HandleFunction_DataRaceMap(t *testing.T) {
 }
 
 func Test_HandleFunction_DataRaceNestedSameFunction(t *testing.T) {
-	f, pkg := LoadMain(t, "./testdata/Functions/General/DataRaceNestedSameFunction/prog1.go")
+	f, _ := LoadMain(t, "./testdata/Functions/General/DataRaceNestedSameFunction/prog1.go")
 	ctx := domain.NewEmptyContext()
 	state := HandleFunction(ctx, f)
-	conflictingAccesses, err := pointerAnalysis.Analysis(pkg, state.GuardedAccesses)
-	require.NoError(t, err)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
 	gas := FindMultipleGA(state.GuardedAccesses, func(ga *domain.GuardedAccess) bool {
 		global, ok := ga.Value.(*ssa.Global)
 		if !ok {
@@ -779,11 +861,10 @@ func Test_HandleFunction_DataRaceNestedSameFunction(t *testing.T) {
 }
 
 func Test_HandleFunction_DataRaceProperty(t *testing.T) {
-	f, pkg := LoadMain(t, "./testdata/Functions/General/DataRaceProperty/prog1.go")
+	f, _ := LoadMain(t, "./testdata/Functions/General/DataRaceProperty/prog1.go")
 	ctx := domain.NewEmptyContext()
 	state := HandleFunction(ctx, f)
-	conflictingAccesses, err := pointerAnalysis.Analysis(pkg, state.GuardedAccesses)
-	require.NoError(t, err)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
 	gaA := FindGAWithFail(t, state.GuardedAccesses, func(ga *domain.GuardedAccess) bool {
 		if !IsGAWrite(ga) {
 			return false
@@ -822,11 +903,10 @@ func Test_HandleFunction_DataRaceProperty(t *testing.T) {
 }
 
 func Test_HandleFunction_DataRaceRecursion(t *testing.T) {
-	f, pkg := LoadMain(t, "./testdata/Functions/General/DataRaceRecursion/prog1.go")
+	f, _ := LoadMain(t, "./testdata/Functions/General/DataRaceRecursion/prog1.go")
 	ctx := domain.NewEmptyContext()
 	state := HandleFunction(ctx, f)
-	conflictingAccesses, err := pointerAnalysis.Analysis(pkg, state.GuardedAccesses)
-	require.NoError(t, err)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
 	gas := FindMultipleGAWithFail(t, state.GuardedAccesses, func(ga *domain.GuardedAccess) bool {
 		if !IsGAWrite(ga) {
 			return false
@@ -863,22 +943,21 @@ func Test_HandleFunction_DataRaceRecursion(t *testing.T) {
 }
 
 func Test_HandleFunction_DataRaceShadowedErr(t *testing.T) {
-	f, pkg := LoadMain(t, "./testdata/Functions/General/DataRaceShadowedErr/prog1.go")
+	f, _ := LoadMain(t, "./testdata/Functions/General/DataRaceShadowedErr/prog1.go")
 	ctx := domain.NewEmptyContext()
 	state := HandleFunction(ctx, f)
-	conflictingAccesses, err := pointerAnalysis.Analysis(pkg, state.GuardedAccesses)
-	require.NoError(t, err)
-	assert.Len(t, conflictingAccesses, 6)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	// Both goroutines share err with the main goroutine and with each other
+	assert.Len(t, conflictingAccesses, 14)
 	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
-	assert.Len(t, filteredAccesses, 3)
+	assert.Len(t, filteredAccesses, 7)
 }
 
 func Test_HandleFunction_DataRaceWithOnlyAlloc(t *testing.T) {
-	f, pkg := LoadMain(t, "./testdata/Functions/General/DataRaceWithOnlyAlloc/prog1.go")
+	f, _ := LoadMain(t, "./testdata/Functions/General/DataRaceWithOnlyAlloc/prog1.go")
 	ctx := domain.NewEmptyContext()
 	state := HandleFunction(ctx, f)
-	conflictingAccesses, err := pointerAnalysis.Analysis(pkg, state.GuardedAccesses)
-	require.NoError(t, err)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
 	assert.Len(t, conflictingAccesses, 2)
 	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
 	assert.Len(t, filteredAccesses, 1)
@@ -903,12 +982,11 @@ func Test_HandleFunction_DataRaceWithOnlyAlloc(t *testing.T) {
 }
 
 func Test_HandleFunction_DataRaceWithSameFunction(t *testing.T) {
-	f, pkg := LoadMain(t, "./testdata/Functions/General/DataRaceWithSameFunction/prog1.go")
+	f, _ := LoadMain(t, "./testdata/Functions/General/DataRaceWithSameFunction/prog1.go")
 	ctx := domain.NewEmptyContext()
 	entryCallCommon := ssa.CallCommon{Value: f}
 	state := HandleCallCommon(ctx, &entryCallCommon, f.Pos())
-	conflictingAccesses, err := pointerAnalysis.Analysis(pkg, state.GuardedAccesses)
-	require.NoError(t, err)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
 	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
 	assert.Len(t, filteredAccesses, 1)
 
@@ -1035,7 +1113,7 @@ func Test_HandleFunction_StructMethod(t *testing.T) {
 }
 
 func Test_HandleFunction_DataRaceInterfaceOverChannel(t *testing.T) {
-	f, pkg := LoadMain(t, "./testdata/Functions/PointerAnalysis/DataRaceInterfaceOverChannel/prog1.go")
+	f, _ := LoadMain(t, "./testdata/Functions/PointerAnalysis/DataRaceInterfaceOverChannel/prog1.go")
 	ctx := domain.NewEmptyContext()
 	state := HandleFunction(ctx, f)
 
@@ -1047,8 +1125,7 @@ func Test_HandleFunction_DataRaceInterfaceOverChannel(t *testing.T) {
 		return ok
 	}, 2)
 
-	conflictingAccesses, err := pointerAnalysis.Analysis(pkg, state.GuardedAccesses)
-	require.NoError(t, err)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
 	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
 	require.Len(t, filteredAccesses, 1)
 	found := false
@@ -1059,3 +1136,682 @@ func Test_HandleFunction_DataRaceInterfaceOverChannel(t *testing.T) {
 	}
 	assert.True(t, found)
 }
+
+func Test_HandleFunction_UnbufferedChannel(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/Channels/UnbufferedChannel/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	assert.Len(t, conflictingAccesses, 0)
+}
+
+func Test_HandleFunction_SendToGoroutine(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/Channels/SendToGoroutine/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	assert.Len(t, conflictingAccesses, 0)
+}
+
+func Test_HandleFunction_BufferedChannelRace(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/Channels/BufferedChannelRace/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	assert.True(t, IsGAWrite(filteredAccesses[0][0]))
+	assert.True(t, IsGAWrite(filteredAccesses[0][1]))
+}
+
+func Test_HandleFunction_BufferedChannelCapacity(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/Channels/BufferedChannelCapacity/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	assert.Len(t, conflictingAccesses, 0)
+}
+
+func Test_HandleFunction_CloseChannel(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/Channels/CloseChannel/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	assert.Len(t, conflictingAccesses, 0)
+}
+
+func Test_HandleFunction_SelectWithDefault(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/Channels/SelectWithDefault/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	assert.Len(t, filteredAccesses, 1)
+}
+
+func Test_HandleFunction_WaitGroup(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/WaitGroups/WaitGroup/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	assert.Len(t, conflictingAccesses, 0)
+}
+
+func Test_HandleFunction_WaitGroupRace(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/WaitGroups/WaitGroupRace/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	assert.True(t, IsGAWrite(filteredAccesses[0][0]))
+	assert.True(t, IsGAWrite(filteredAccesses[0][1]))
+}
+
+func Test_HandleFunction_WaitGroupReused(t *testing.T) {
+	f, pkg := LoadMain(t, "./testdata/Functions/WaitGroups/WaitGroupReused/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	// The Done of the second goroutine isn't released to the first Wait
+	require.Len(t, filteredAccesses, 1)
+	assert.ElementsMatch(t, [][2]int{{15, 18}}, GetConflictLines(pkg.Prog, filteredAccesses))
+}
+
+func Test_HandleFunction_WaitGroupReusedOrdered(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/WaitGroups/WaitGroupReusedOrdered/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	assert.Len(t, conflictingAccesses, 0)
+}
+
+func Test_HandleFunction_WaitGroupInStruct(t *testing.T) {
+	f, pkg := LoadMain(t, "./testdata/Functions/WaitGroups/WaitGroupInStruct/prog1.go")
+	ctx := domain.NewEmptyContext()
+	entryCallCommon := ssa.CallCommon{Value: f}
+	state := HandleCallCommon(ctx, &entryCallCommon, f.Pos())
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	posA := pkg.Prog.Fset.Position(filteredAccesses[0][0].Pos)
+	posB := pkg.Prog.Fset.Position(filteredAccesses[0][1].Pos)
+	assert.ElementsMatch(t, []int{12, 22}, []int{posA.Line, posB.Line})
+}
+
+func Test_HandleFunction_RWMutexReadAndWrite(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/LocksAndUnlocks/RWMutexReadAndWrite/prog1.go")
+	ctx := domain.NewEmptyContext()
+	entryCallCommon := ssa.CallCommon{Value: f}
+	state := HandleCallCommon(ctx, &entryCallCommon, f.Pos())
+
+	ga := FindGAWithFail(t, state.GuardedAccesses, func(ga *domain.GuardedAccess) bool {
+		if !IsGAWrite(ga) {
+			return false
+		}
+		_, ok := ga.Value.(*ssa.FieldAddr)
+		return ok
+	})
+	require.Len(t, ga.Lockset.Locks, 1)
+	for lock := range ga.Lockset.Locks {
+		assert.False(t, lock.IsRead)
+	}
+
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	assert.Len(t, conflictingAccesses, 0)
+}
+
+func Test_HandleFunction_RWMutexWriteUnderRLock(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/LocksAndUnlocks/RWMutexWriteUnderRLock/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	assert.Len(t, state.Lockset.Locks, 0)
+	assert.Len(t, state.Lockset.Unlocks, 1)
+
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	for _, ga := range filteredAccesses[0] {
+		assert.True(t, IsGAWrite(ga))
+		require.Len(t, ga.Lockset.Locks, 1)
+		for lock := range ga.Lockset.Locks {
+			assert.True(t, lock.IsRead)
+		}
+	}
+}
+
+func Test_HandleFunction_AtomicCounter(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/Atomics/AtomicCounter/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	atomicAccesses := 0
+	for _, ga := range state.GuardedAccesses {
+		if ga.OpKind.IsAtomic() {
+			atomicAccesses++
+		}
+	}
+	assert.Equal(t, 3, atomicAccesses)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	assert.Len(t, conflictingAccesses, 0)
+}
+
+func Test_HandleFunction_MixedAtomic(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/Atomics/MixedAtomic/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	assert.True(t, filteredAccesses[0][0].IsMixedAtomic(filteredAccesses[0][1]))
+	kinds := []domain.OpKind{filteredAccesses[0][0].OpKind, filteredAccesses[0][1].OpKind}
+	assert.ElementsMatch(t, []domain.OpKind{domain.GuardAccessRead, domain.GuardAccessAtomicWrite}, kinds)
+}
+
+func Test_HandleFunction_TypedAtomic(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/Atomics/TypedAtomic/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	assert.Len(t, conflictingAccesses, 0)
+}
+
+func Test_HandleFunction_OnceDo(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/Once/OnceDo/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	writes := 0
+	for _, ga := range state.GuardedAccesses {
+		if _, ok := ga.Value.(*ssa.FieldAddr); ok && IsGAWrite(ga) {
+			writes++
+		}
+	}
+	assert.Equal(t, 1, writes) // The once function is analyzed once
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	assert.Len(t, conflictingAccesses, 0)
+}
+
+func Test_HandleFunction_OnceDoRace(t *testing.T) {
+	f, pkg := LoadMain(t, "./testdata/Functions/Once/OnceDoRace/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	posA := pkg.Prog.Fset.Position(filteredAccesses[0][0].Pos)
+	posB := pkg.Prog.Fset.Position(filteredAccesses[0][1].Pos)
+	assert.ElementsMatch(t, []int{10, 14}, []int{posA.Line, posB.Line})
+}
+
+func Test_HandleFunction_OnceValue(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/Once/OnceValue/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	FindGAWithFail(t, state.GuardedAccesses, func(ga *domain.GuardedAccess) bool {
+		_, ok := ga.Value.(*ssa.UnOp)
+		return IsGAWrite(ga) && ok
+	})
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	assert.Len(t, conflictingAccesses, 0)
+}
+
+func Test_HandleFunction_LockThroughPointer(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/LocksAndUnlocks/LockThroughPointer/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	assert.Len(t, conflictingAccesses, 0)
+}
+
+func Test_HandleFunction_LockDifferentInstances(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/LocksAndUnlocks/LockDifferentInstances/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	for _, ga := range filteredAccesses[0] {
+		assert.True(t, IsGAWrite(ga))
+		require.Len(t, ga.Lockset.Locks, 1)
+	}
+}
+
+func Test_HandleFunction_GenericCacheLocked(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/Generics/CacheLocked/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	assert.Len(t, conflictingAccesses, 0)
+}
+
+func Test_HandleFunction_GenericCacheRace(t *testing.T) {
+	f, pkg := LoadMain(t, "./testdata/Functions/Generics/CacheRace/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	assert.ElementsMatch(t, [][2]int{{17, 21}}, GetConflictLines(pkg.Prog, filteredAccesses))
+}
+
+func Test_HandleFunction_GenericInstancesRace(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/Generics/InstancesRace/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	for _, ga := range filteredAccesses[0] {
+		// Only the instance of the unlocked box races
+		typeArgs := ga.Value.(*ssa.FieldAddr).Parent().TypeArgs()
+		require.Len(t, typeArgs, 1)
+		assert.True(t, types.Identical(types.Typ[types.String], typeArgs[0]))
+	}
+}
+
+func Test_HandleFunction_GenericInterfaceRace(t *testing.T) {
+	f, pkg := LoadMain(t, "./testdata/Functions/Generics/InterfaceRace/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 4)
+	// The locked store doesn't race, only the appends of the instance of the unlocked store
+	assert.ElementsMatch(t, [][2]int{{14, 14}, {14, 14}, {14, 14}, {14, 14}}, GetConflictLines(pkg.Prog, filteredAccesses))
+}
+
+func Test_HandleFunction_GenericConstraintMethods(t *testing.T) {
+	f, pkg := LoadMain(t, "./testdata/Functions/Generics/ConstraintMethods/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	assert.Equal(t, 25, pkg.Prog.Fset.Position(filteredAccesses[0][0].Pos).Line)
+	assert.Equal(t, 25, pkg.Prog.Fset.Position(filteredAccesses[0][1].Pos).Line)
+}
+
+func Test_HandleFunction_GenericSameSignature(t *testing.T) {
+	f, pkg := LoadMain(t, "./testdata/Functions/Generics/SameSignature/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	posA := pkg.Prog.Fset.Position(filteredAccesses[0][0].Pos)
+	posB := pkg.Prog.Fset.Position(filteredAccesses[0][1].Pos)
+	assert.ElementsMatch(t, []int{4, 8}, []int{posA.Line, posB.Line})
+}
+
+func Test_HandleFunction_SummaryLockThroughParameter(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/Summaries/LockThroughParameter/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	assert.Len(t, conflictingAccesses, 0)
+}
+
+func Test_HandleFunction_SummaryAliasedParameters(t *testing.T) {
+	f, pkg := LoadMain(t, "./testdata/Functions/Summaries/AliasedParameters/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	posA := pkg.Prog.Fset.Position(filteredAccesses[0][0].Pos)
+	posB := pkg.Prog.Fset.Position(filteredAccesses[0][1].Pos)
+	assert.ElementsMatch(t, []int{14, 31}, []int{posA.Line, posB.Line})
+}
+
+func Test_HandleFunction_SummaryStartsGoroutine(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/Summaries/StartsGoroutine/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	goroutines := make(map[int]struct{})
+	for _, ga := range state.GuardedAccesses {
+		if _, ok := ga.Value.(*ssa.Global); ok && IsGAWrite(ga) {
+			goroutines[ga.State.GoroutineID] = struct{}{}
+		}
+	}
+	// Each call to spawn starts a goroutine of its own
+	assert.Len(t, goroutines, 2)
+	assert.NotContains(t, goroutines, ctx.GoroutineID)
+}
+
+func Test_HandleFunction_DynamicFuncParameter(t *testing.T) {
+	f, pkg := LoadMain(t, "./testdata/Functions/DynamicCalls/FuncParameter/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	assert.ElementsMatch(t, [][2]int{{11, 11}}, GetConflictLines(pkg.Prog, filteredAccesses))
+}
+
+func Test_HandleFunction_DynamicStructField(t *testing.T) {
+	f, pkg := LoadMain(t, "./testdata/Functions/DynamicCalls/StructField/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	assert.ElementsMatch(t, [][2]int{{10, 10}}, GetConflictLines(pkg.Prog, filteredAccesses))
+}
+
+func Test_HandleFunction_DynamicMapEntry(t *testing.T) {
+	f, pkg := LoadMain(t, "./testdata/Functions/DynamicCalls/MapEntry/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	assert.ElementsMatch(t, [][2]int{{8, 8}}, GetConflictLines(pkg.Prog, filteredAccesses))
+}
+
+func Test_HandleFunction_DynamicReturnedClosure(t *testing.T) {
+	f, pkg := LoadMain(t, "./testdata/Functions/DynamicCalls/ReturnedClosure/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 2)
+	assert.ElementsMatch(t, [][2]int{{6, 6}, {6, 7}}, GetConflictLines(pkg.Prog, filteredAccesses))
+}
+
+func Test_HandleFunction_DynamicLockedFuncValue(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/DynamicCalls/LockedFuncValue/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	assert.Len(t, conflictingAccesses, 0)
+}
+
+func Test_HandleFunction_DynamicMethodExpression(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/DynamicCalls/MethodExpression/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	assert.Len(t, conflictingAccesses, 0)
+}
+
+func Test_HandleFunction_DynamicBoundLock(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/DynamicCalls/BoundLock/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	assert.Len(t, conflictingAccesses, 0)
+}
+
+func Test_HandleFunction_DynamicBoundWaitGroupDone(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/DynamicCalls/BoundWaitGroupDone/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	assert.Len(t, conflictingAccesses, 0)
+}
+
+func Test_HandleFunction_DispatchTypesUnreachableConversion(t *testing.T) {
+	f, pkg := LoadMainWithDispatch(t, "./testdata/Functions/Dispatch/UnreachableConversion/prog1.go", DispatchTypes)
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	assert.ElementsMatch(t, [][2]int{{25, 25}}, GetConflictLines(pkg.Prog, filteredAccesses))
+}
+
+func Test_HandleFunction_DispatchCHAUnreachableConversion(t *testing.T) {
+	f, pkg := LoadMainWithDispatch(t, "./testdata/Functions/Dispatch/UnreachableConversion/prog1.go", DispatchCHA)
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	assert.ElementsMatch(t, [][2]int{{25, 25}}, GetConflictLines(pkg.Prog, filteredAccesses))
+}
+
+func Test_HandleFunction_DispatchRTAUnreachableConversion(t *testing.T) {
+	f, _ := LoadMainWithDispatch(t, "./testdata/Functions/Dispatch/UnreachableConversion/prog1.go", DispatchRTA)
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 0)
+}
+
+func Test_HandleFunction_DispatchRTAOtherInterface(t *testing.T) {
+	f, pkg := LoadMainWithDispatch(t, "./testdata/Functions/Dispatch/OtherInterface/prog1.go", DispatchRTA)
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	assert.ElementsMatch(t, [][2]int{{28, 28}}, GetConflictLines(pkg.Prog, filteredAccesses))
+}
+
+func Test_HandleFunction_DispatchVTAValueReceiverThroughPointer(t *testing.T) {
+	f, pkg := LoadMainWithDispatch(t, "./testdata/Functions/Dispatch/ValueReceiverThroughPointer/prog1.go", DispatchVTA)
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	assert.ElementsMatch(t, [][2]int{{12, 12}}, GetConflictLines(pkg.Prog, filteredAccesses))
+}
+
+func Test_HandleFunction_DispatchVTAPromotedMethod(t *testing.T) {
+	f, pkg := LoadMainWithDispatch(t, "./testdata/Functions/Dispatch/PromotedMethod/prog1.go", DispatchVTA)
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	assert.ElementsMatch(t, [][2]int{{12, 12}}, GetConflictLines(pkg.Prog, filteredAccesses))
+}
+
+func Test_HandleFunction_DispatchVTAOtherInterface(t *testing.T) {
+	f, _ := LoadMainWithDispatch(t, "./testdata/Functions/Dispatch/OtherInterface/prog1.go", DispatchVTA)
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 0)
+}
+
+func Test_HandleFunction_DeepCalls(t *testing.T) {
+	// Each function of the chain calls the next one, and the last one increments a global
+	const callsAmount = 5000
+	source := &strings.Builder{}
+	source.WriteString("package main\n\nvar count int\n\n")
+	for i := 0; i < callsAmount-1; i++ {
+		fmt.Fprintf(source, "func f%d() { f%d() }\n", i, i+1)
+	}
+	countLine := strings.Count(source.String(), "\n") + 2
+	fmt.Fprintf(source, "func f%d() {\n\tcount++\n}\n\nfunc main() {\n\tgo f0()\n\tf0()\n}\n", callsAmount-1)
+	f, pkg := LoadMainFromSource(t, source.String())
+	ctx := domain.NewEmptyContext()
+	// The traversal is iterative, so thousands of nested calls fit in a small stack
+	maxStack := debug.SetMaxStack(1 << 20)
+	state := HandleFunction(ctx, f)
+	debug.SetMaxStack(maxStack)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	assert.Equal(t, [][2]int{{countLine, countLine}}, GetConflictLines(pkg.Prog, filteredAccesses))
+}
+
+func Test_HandleFunction_BranchesShortCircuit(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/Branches/ShortCircuit/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 0)
+}
+
+func Test_HandleFunction_BranchesPredicateOnLocal(t *testing.T) {
+	f, pkg := LoadMain(t, "./testdata/Functions/Branches/PredicateOnLocal/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	assert.ElementsMatch(t, [][2]int{{9, 18}}, GetConflictLines(pkg.Prog, filteredAccesses))
+}
+
+func Test_HandleFunction_EscapeLocalAllocation(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/Escape/LocalAllocation/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 0)
+}
+
+func Test_HandleFunction_EscapeSentOnChannel(t *testing.T) {
+	f, pkg := LoadMain(t, "./testdata/Functions/Escape/SentOnChannel/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	assert.ElementsMatch(t, [][2]int{{12, 15}}, GetConflictLines(pkg.Prog, filteredAccesses))
+}
+
+func Test_HandleFunction_ElementsConstantIndices(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/Elements/ConstantIndices/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 0)
+}
+
+func Test_HandleFunction_ElementsSameConstantIndex(t *testing.T) {
+	f, pkg := LoadMain(t, "./testdata/Functions/Elements/SameConstantIndex/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	assert.ElementsMatch(t, [][2]int{{7, 10}}, GetConflictLines(pkg.Prog, filteredAccesses))
+}
+
+func Test_HandleFunction_ElementsResliced(t *testing.T) {
+	f, pkg := LoadMain(t, "./testdata/Functions/Elements/Resliced/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	assert.ElementsMatch(t, [][2]int{{8, 11}}, GetConflictLines(pkg.Prog, filteredAccesses))
+}
+
+func Test_HandleFunction_ElementsWorkersInLoop(t *testing.T) {
+	f, pkg := LoadMain(t, "./testdata/Functions/Elements/WorkersInLoop/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 3)
+	// The elements of the iterations don't race with each other, only with the increments of the first element
+	assert.ElementsMatch(t, [][2]int{{13, 13}, {12, 13}, {12, 13}}, GetConflictLines(pkg.Prog, filteredAccesses))
+}
+
+func Test_HandleFunction_ElementsOuterLoopIndex(t *testing.T) {
+	f, pkg := LoadMain(t, "./testdata/Functions/Elements/OuterLoopIndex/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	assert.ElementsMatch(t, [][2]int{{8, 8}}, GetConflictLines(pkg.Prog, filteredAccesses))
+}
+
+func Test_HandleFunction_StructsNestedSiblingFields(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/Structs/NestedSiblingFields/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 0)
+}
+
+func Test_HandleFunction_StructsStructCopy(t *testing.T) {
+	f, pkg := LoadMain(t, "./testdata/Functions/Structs/StructCopy/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 1)
+	assert.ElementsMatch(t, [][2]int{{16, 18}}, GetConflictLines(pkg.Prog, filteredAccesses))
+}
+
+func Test_HandleFunction_StructsMutexOverwritten(t *testing.T) {
+	f, pkg := LoadMain(t, "./testdata/Functions/Structs/MutexOverwritten/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 3)
+	// Overwriting the struct overwrites its mutex too, so it conflicts with locking and unlocking the mutex.
+	assert.ElementsMatch(t, [][2]int{{14, 19}, {15, 19}, {16, 19}}, GetConflictLines(pkg.Prog, filteredAccesses))
+}
+
+func Test_HandleFunction_StructsEmbeddedFields(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/Structs/EmbeddedFields/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	conflictingAccesses := pointerAnalysis.Analysis(GlobalPointerAnalysis, state.GuardedAccesses)
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingAccesses)
+	require.Len(t, filteredAccesses, 0)
+}
+
+func Test_HandleFunction_DeadlocksLockOrderInversion(t *testing.T) {
+	f, pkg := LoadMain(t, "./testdata/Functions/Deadlocks/LockOrderInversion/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	deadlocks := pointerAnalysis.FilterDuplicateDeadlocks(pointerAnalysis.FindDeadlocks(state.GuardedAccesses))
+	require.Len(t, deadlocks, 1)
+	require.Len(t, deadlocks[0], 2)
+	lines := []int{pkg.Prog.Fset.Position(deadlocks[0][0].Pos).Line, pkg.Prog.Fset.Position(deadlocks[0][1].Pos).Line}
+	assert.ElementsMatch(t, []int{10, 16}, lines)
+	assert.NotEqual(t, deadlocks[0][0].State.GoroutineID, deadlocks[0][1].State.GoroutineID)
+}
+
+func Test_HandleFunction_DeadlocksInversionThroughParams(t *testing.T) {
+	f, pkg := LoadMain(t, "./testdata/Functions/Deadlocks/InversionThroughParams/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	deadlocks := pointerAnalysis.FilterDuplicateDeadlocks(pointerAnalysis.FindDeadlocks(state.GuardedAccesses))
+	require.Len(t, deadlocks, 1)
+	require.Len(t, deadlocks[0], 2)
+	for _, acquisition := range deadlocks[0] {
+		assert.Equal(t, 7, pkg.Prog.Fset.Position(acquisition.Pos).Line)
+	}
+	assert.False(t, deadlocks[0][0].Mutex.IsSameMutex(deadlocks[0][1].Mutex))
+}
+
+func Test_HandleFunction_DeadlocksGateLock(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/Deadlocks/GateLock/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	deadlocks := pointerAnalysis.FindDeadlocks(state.GuardedAccesses)
+	require.Len(t, deadlocks, 0)
+}
+
+func Test_HandleFunction_DeadlocksOrderedByChannel(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/Deadlocks/OrderedByChannel/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	deadlocks := pointerAnalysis.FindDeadlocks(state.GuardedAccesses)
+	require.Len(t, deadlocks, 0)
+}
+
+func Test_HandleFunction_DeadlocksReadLocks(t *testing.T) {
+	f, _ := LoadMain(t, "./testdata/Functions/Deadlocks/ReadLocks/prog1.go")
+	ctx := domain.NewEmptyContext()
+	state := HandleFunction(ctx, f)
+	deadlocks := pointerAnalysis.FindDeadlocks(state.GuardedAccesses)
+	require.Len(t, deadlocks, 0)
+}
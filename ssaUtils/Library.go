@@ -0,0 +1,287 @@
+package ssaUtils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/types"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// HarnessDir is the directory, inside the directory of a library package, of the entry point synthesized for it. The
+// entry point only exists in memory.
+const HarnessDir = "chronosharness"
+
+// harnessFile is the name of the file of the synthesized entry point.
+const harnessFile = "main.go"
+
+var ErrNoAPI = errors.New("no exported functions to call in the packages")
+
+// LoadLibraries loads the packages matching the go list patterns as libraries. Since a library has no main function,
+// a main package is synthesized for each of them, in which every exported function and method is called from two
+// goroutines concurrently. Methods of the same type are called on a single shared receiver, so races between calls to
+// the API are found. The returned packages are the synthesized main packages.
+// If api isn't empty, only the functions matching its patterns are called. Functions are matched by their name, and
+// methods by the name of their type and their name, like Counter.Inc or Counter.*.
+func LoadLibraries(patterns []string, dir string, api []string) (*ssa.Program, []*ssa.Package, error) {
+	conf := packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedTypes,
+		Dir:  dir,
+	}
+	pkgs, err := packages.Load(&conf, patterns...)
+	if err != nil {
+		return nil, nil, err
+	}
+	overlay := make(map[string][]byte)
+	harnessDirs := make([]string, 0)
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			return nil, nil, fmt.Errorf("%w %s: %s", ErrLoadPackages, pkg.PkgPath, pkg.Errors[0].Msg)
+		}
+		if pkg.Name == "main" || len(pkg.GoFiles) == 0 {
+			continue
+		}
+		src, ok := generateHarness(pkg.Types, pkg.PkgPath+"/"+HarnessDir, api)
+		if !ok {
+			continue
+		}
+		harnessDir := filepath.Join(filepath.Dir(pkg.GoFiles[0]), HarnessDir)
+		overlay[filepath.Join(harnessDir, harnessFile)] = src
+		harnessDirs = append(harnessDirs, harnessDir)
+	}
+	if len(harnessDirs) == 0 {
+		return nil, nil, fmt.Errorf("%s: %w", strings.Join(patterns, " "), ErrNoAPI)
+	}
+
+	conf = packages.Config{
+		Mode:    packages.LoadAllSyntax,
+		Dir:     dir,
+		Overlay: overlay,
+	}
+	pkgs, err = packages.Load(&conf, harnessDirs...)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			return nil, nil, fmt.Errorf("%w %s: %s", ErrLoadPackages, pkg.PkgPath, pkg.Errors[0].Msg)
+		}
+	}
+	ssaProg, ssaPkgs := ssautil.AllPackages(pkgs, builderMode)
+	ssaProg.Build()
+	return ssaProg, ssaPkgs, nil
+}
+
+// isHarnessFile reports whether the file is the entry point synthesized for a library.
+func isHarnessFile(filename string) bool {
+	return filepath.Base(filename) == harnessFile && filepath.Base(filepath.Dir(filename)) == HarnessDir
+}
+
+// harness writes the source of the main package that calls the API of a library.
+type harness struct {
+	pkgPath   string
+	api       []string
+	imports   map[string]string // The names of the imported packages by their path
+	receivers int
+	body      bytes.Buffer
+}
+
+// generateHarness returns the source of the main package of the library, or false if there is nothing to call.
+func generateHarness(pkg *types.Package, harnessPath string, api []string) ([]byte, bool) {
+	h := &harness{pkgPath: harnessPath, api: api, imports: make(map[string]string)}
+	hasCalls := false
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		switch obj := scope.Lookup(name).(type) {
+		case *types.Func:
+			if !obj.Exported() || !h.isSelected(name) {
+				continue
+			}
+			sig := obj.Type().(*types.Signature)
+			if call, ok := h.getCall(name, sig); ok {
+				h.addCall(h.qualify(pkg) + "." + call)
+				hasCalls = true
+			}
+		case *types.TypeName:
+			if obj.Exported() && !obj.IsAlias() {
+				hasCalls = h.addMethodCalls(obj) || hasCalls
+			}
+		}
+	}
+	if !hasCalls {
+		return nil, false
+	}
+
+	var src bytes.Buffer
+	src.WriteString("// Code generated by Chronos. DO NOT EDIT.\n\npackage main\n\nimport (\n")
+	paths := make([]string, 0, len(h.imports))
+	for importPath := range h.imports {
+		paths = append(paths, importPath)
+	}
+	sort.Strings(paths)
+	for _, importPath := range paths {
+		fmt.Fprintf(&src, "\t%s %q\n", h.imports[importPath], importPath)
+	}
+	src.WriteString(")\n\nfunc main() {\n")
+	src.Write(h.body.Bytes())
+	src.WriteString("}\n")
+	return src.Bytes(), true
+}
+
+// addMethodCalls calls the exported methods of the type on a receiver shared by all the calls.
+func (h *harness) addMethodCalls(typeName *types.TypeName) bool {
+	named, ok := typeName.Type().(*types.Named)
+	if !ok || named.TypeParams().Len() > 0 || types.IsInterface(named) {
+		return false
+	}
+	receiver := fmt.Sprintf("r%d", h.receivers)
+	calls := make([]string, 0)
+	methods := types.NewMethodSet(types.NewPointer(named))
+	for i := 0; i < methods.Len(); i++ {
+		method := methods.At(i).Obj()
+		if !method.Exported() || !h.isSelected(typeName.Name()+"."+method.Name()) {
+			continue
+		}
+		if call, ok := h.getCall(receiver+"."+method.Name(), method.Type().(*types.Signature)); ok {
+			calls = append(calls, call)
+		}
+	}
+	if len(calls) == 0 {
+		return false
+	}
+	h.receivers++
+	fmt.Fprintf(&h.body, "\t%s := new(%s)\n", receiver, types.TypeString(named, h.qualify))
+	for _, call := range calls {
+		h.addCall(call)
+	}
+	return true
+}
+
+// addCall calls the function from two goroutines.
+func (h *harness) addCall(call string) {
+	fmt.Fprintf(&h.body, "\tgo %s\n", call)
+	fmt.Fprintf(&h.body, "\tgo %s\n", call)
+}
+
+// getCall returns a call to the function with the zero values of its parameters, or false if a parameter can't be
+// written in the harness.
+func (h *harness) getCall(fn string, sig *types.Signature) (string, bool) {
+	if sig.TypeParams().Len() > 0 {
+		return "", false
+	}
+	params := sig.Params()
+	for i := 0; i < params.Len(); i++ {
+		if !h.isNameable(params.At(i).Type()) {
+			return "", false
+		}
+	}
+	args := make([]string, 0, params.Len())
+	for i := 0; i < params.Len(); i++ {
+		arg := fmt.Sprintf("*new(%s)", types.TypeString(params.At(i).Type(), h.qualify))
+		if sig.Variadic() && i == params.Len()-1 {
+			arg += "..."
+		}
+		args = append(args, arg)
+	}
+	return fmt.Sprintf("%s(%s)", fn, strings.Join(args, ", ")), true
+}
+
+func (h *harness) isSelected(name string) bool {
+	if len(h.api) == 0 {
+		return true
+	}
+	for _, pattern := range h.api {
+		if isMatch, _ := path.Match(pattern, name); isMatch {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *harness) qualify(pkg *types.Package) string {
+	name, ok := h.imports[pkg.Path()]
+	if !ok {
+		name = fmt.Sprintf("p%d", len(h.imports))
+		h.imports[pkg.Path()] = name
+	}
+	return name
+}
+
+// isNameable returns whether the type can be written in the source of the harness.
+func (h *harness) isNameable(typ types.Type) bool {
+	switch typ := typ.(type) {
+	case *types.Basic:
+		return typ.Kind() != types.Invalid && typ.Kind() != types.UnsafePointer
+	case *types.Named:
+		obj := typ.Obj()
+		if obj.Pkg() == nil { // Predeclared, like error
+			return true
+		}
+		if !obj.Exported() || obj.Parent() != obj.Pkg().Scope() || !canImport(h.pkgPath, obj.Pkg().Path()) {
+			return false
+		}
+		for i := 0; i < typ.TypeArgs().Len(); i++ {
+			if !h.isNameable(typ.TypeArgs().At(i)) {
+				return false
+			}
+		}
+		return true
+	case *types.Pointer:
+		return h.isNameable(typ.Elem())
+	case *types.Slice:
+		return h.isNameable(typ.Elem())
+	case *types.Array:
+		return h.isNameable(typ.Elem())
+	case *types.Chan:
+		return h.isNameable(typ.Elem())
+	case *types.Map:
+		return h.isNameable(typ.Key()) && h.isNameable(typ.Elem())
+	case *types.Signature:
+		return h.isTupleNameable(typ.Params()) && h.isTupleNameable(typ.Results())
+	case *types.Struct:
+		for i := 0; i < typ.NumFields(); i++ {
+			if !typ.Field(i).Exported() || !h.isNameable(typ.Field(i).Type()) {
+				return false
+			}
+		}
+		return true
+	case *types.Interface:
+		if !typ.IsMethodSet() {
+			return false
+		}
+		for i := 0; i < typ.NumMethods(); i++ {
+			if !typ.Method(i).Exported() || !h.isNameable(typ.Method(i).Type()) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func (h *harness) isTupleNameable(tuple *types.Tuple) bool {
+	for i := 0; i < tuple.Len(); i++ {
+		if !h.isNameable(tuple.At(i).Type()) {
+			return false
+		}
+	}
+	return true
+}
+
+// canImport returns whether the importer may import the package, according to the rules of internal packages.
+func canImport(importer, pkgPath string) bool {
+	parts := strings.Split(pkgPath, "/")
+	for i := len(parts) - 1; i >= 0; i-- {
+		if parts[i] == "internal" {
+			parent := strings.Join(parts[:i], "/")
+			return parent != "" && (importer == parent || strings.HasPrefix(importer, parent+"/"))
+		}
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package ssaUtils
+
+import (
+	"errors"
+	"github.com/pdufour/Chronos/domain"
+	"github.com/pdufour/Chronos/pointerAnalysis"
+	"github.com/pdufour/Chronos/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func analyzeLibrary(t *testing.T, api []string) [][]*domain.GuardedAccess {
+	domain.GoroutineCounter = utils.NewCounter()
+	domain.GuardedAccessCounter = utils.NewCounter()
+	domain.PosIDCounter = utils.NewCounter()
+	_, ex, _, ok := runtime.Caller(0)
+	require.True(t, ok)
+	modulePath := filepath.Dir(filepath.Dir(ex))
+
+	prog, harnessPkgs, err := LoadLibraries([]string{"./testdata/Library/counter"}, "", api)
+	require.NoError(t, err)
+	require.Len(t, harnessPkgs, 1)
+	InitPreProcess(prog, LoadTestScope(t, modulePath))
+	conflictingGAs := AnalyzeEntryPoint(harnessPkgs[0])
+	return pointerAnalysis.FilterDuplicates(conflictingGAs)
+}
+
+func Test_LoadLibraries_ConcurrentCalls(t *testing.T) {
+	conflictingGAs := analyzeLibrary(t, nil)
+	require.Len(t, conflictingGAs, 1)
+	for _, guardedAccess := range conflictingGAs[0] {
+		assert.Equal(t, 25, GlobalProgram.Fset.Position(guardedAccess.Pos).Line)
+		for _, position := range GetStackPositions(GlobalProgram, guardedAccess) {
+			assert.NotContains(t, position.Filename, HarnessDir)
+		}
+	}
+}
+
+func Test_LoadLibraries_API(t *testing.T) {
+	conflictingGAs := analyzeLibrary(t, []string{"Counter.Inc", "Counter.Value"})
+	assert.Len(t, conflictingGAs, 0)
+}
+
+func Test_LoadLibraries_NoAPI(t *testing.T) {
+	_, _, err := LoadLibraries([]string{"./testdata/Library/counter"}, "", []string{"Missing"})
+	assert.True(t, errors.Is(err, ErrNoAPI))
+}
@@ -2,18 +2,61 @@ package ssaUtils
 
 import (
 	"github.com/pdufour/Chronos/domain"
+	"github.com/pdufour/Chronos/pointerAnalysis"
 	"github.com/pdufour/Chronos/ssaPureUtils"
-	"go/token"
 	"golang.org/x/tools/go/ssa"
 )
 
 func AddLock(funcState *domain.BlockState, call *ssa.CallCommon, isUnlock bool) {
-	recv := call.Args[0]
-	mutexPos := ssaPureUtils.GetMutexPos(recv)
-	lock := map[token.Pos]*ssa.CallCommon{mutexPos: call}
+	updateLockset(funcState, call, isUnlock, false)
+}
+
+// AddRLock updates the lockset with a read lock of an RWMutex.
+func AddRLock(funcState *domain.BlockState, call *ssa.CallCommon, isUnlock bool) {
+	updateLockset(funcState, call, isUnlock, true)
+}
+
+// addLockAcquisition records the acquisition of the mutex before the lockset is updated, so the mutexes held while
+// acquiring it are known once the locks held by the callers are added.
+func addLockAcquisition(funcState *domain.BlockState, context *domain.Context, call *ssa.CallCommon, isRead bool) {
+	mutex := call.Args[0]
+	acquisition := domain.AddLockAcquisition(call.Pos(), mutex, getLockID(mutex, isRead), funcState.Lockset, context)
+	funcState.GuardedAccesses = append(funcState.GuardedAccesses, acquisition)
+}
+
+func updateLockset(funcState *domain.BlockState, call *ssa.CallCommon, isUnlock bool, isRead bool) {
+	lock := map[domain.LockID]*ssa.CallCommon{getLockID(call.Args[0], isRead): call}
 	if isUnlock {
 		funcState.Lockset.UpdateWithNewLockSet(nil, lock)
 	} else {
 		funcState.Lockset.UpdateWithNewLockSet(lock, nil)
 	}
-}
\ No newline at end of file
+}
+
+// getLockID identifies the mutex by its allocation if the pointer analysis found a single one. Otherwise, the mutex
+// can't be told apart from others, and the values that must alias it are used instead. A parameter that must alias an
+// earlier parameter in the current calling context is replaced by it.
+func getLockID(mutex ssa.Value, isRead bool) domain.LockID {
+	if allocs := getPointsTo(mutex); len(allocs) == 1 {
+		return domain.LockID{Site: allocs[0].Site, Path: allocs[0].Path, IsRead: isRead}
+	}
+	root, path := ssaPureUtils.GetMustAliasPath(mutex)
+	if alias, ok := parameterAliases[root]; ok {
+		root = alias
+	}
+	return domain.LockID{Site: root, Path: path, IsRead: isRead}
+}
+
+// getPointsTo returns the allocations the value may point to. Values used for synchronization were queried before the
+// traversal, and other values, like the arguments of calls, are queried on demand.
+func getPointsTo(value ssa.Value) []pointerAnalysis.Allocation {
+	if allocs, ok := GlobalPointsTo[value]; ok {
+		return allocs
+	}
+	if !pointerAnalysis.CanPoint(value.Type()) {
+		return nil
+	}
+	allocs := GlobalPointerAnalysis.PointsTo(value)
+	GlobalPointsTo[value] = allocs
+	return allocs
+}
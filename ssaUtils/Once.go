@@ -0,0 +1,55 @@
+package ssaUtils
+
+import (
+	"github.com/pdufour/Chronos/domain"
+	"github.com/pdufour/Chronos/pointerAnalysis"
+	"golang.org/x/tools/go/ssa"
+)
+
+var oncesCache = make(map[pointerAnalysis.Allocation]*domain.Once)
+
+// handleOnceDo records a call to Do of a sync.Once. The once function is analyzed only by the first call to Do found on
+// the once, and the passed state holds its accesses.
+func handleOnceDo(context *domain.Context, call *ssa.CallCommon, done continuation) {
+	handleOnce(context, GetAllocations(call.Args[0]), call.Args[1], done)
+}
+
+// handleOnceFunc records a call to a function returned by sync.OnceFunc, sync.OnceValue or sync.OnceValues. The call
+// that created the function is used as the allocation site of its once.
+func handleOnceFunc(context *domain.Context, onceFuncCall *ssa.Call, done continuation) {
+	allocs := []pointerAnalysis.Allocation{{Site: onceFuncCall}}
+	handleOnce(context, allocs, onceFuncCall.Call.Args[0], done)
+}
+
+func handleOnce(context *domain.Context, allocs []pointerAnalysis.Allocation, fn ssa.Value, done continuation) {
+	event := newSyncEvent(context, true)
+	notRun := make([]*domain.Once, 0)
+	for _, alloc := range allocs {
+		once, ok := oncesCache[alloc]
+		if !ok {
+			once = domain.NewOnce()
+			oncesCache[alloc] = once
+		}
+		event.AddOperation(once, domain.SyncOpDo)
+		if !once.HasRun() {
+			notRun = append(notRun, once)
+		}
+	}
+
+	if len(notRun) == 0 {
+		context.Acquire(event)
+		resume(done, domain.GetEmptyBlockState())
+		return
+	}
+	onceContext := domain.NewOnceExecutionState(context)
+	start := newSyncEvent(onceContext, true)
+	onceContext.Acquire(start)
+	handleCallCommon(onceContext, &ssa.CallCommon{Value: fn}, fn.Pos(), func(funcState *domain.BlockState) {
+		end := newSyncEvent(onceContext, true)
+		for _, once := range notRun {
+			once.SetRun(start, end)
+		}
+		context.Acquire(event)
+		resume(done, funcState)
+	})
+}
@@ -4,6 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"github.com/pdufour/Chronos/domain"
+	"github.com/pdufour/Chronos/pointerAnalysis"
+	"github.com/pdufour/Chronos/ssaPureUtils"
+	"go/ast"
 	"go/token"
 	"go/types"
 	"golang.org/x/tools/go/loader"
@@ -11,15 +14,31 @@ import (
 	"golang.org/x/tools/go/ssa"
 	"golang.org/x/tools/go/ssa/ssautil"
 	"sort"
+	"strings"
 	"testing"
 )
 
-var typesCache = make(map[*types.Interface][]*ssa.Function)
+var typesCache = make(map[methodKey][]*ssa.Function)
 var GlobalProgram *ssa.Program
-var GlobalModuleName string
+var GlobalScope *Scope
+var GlobalPointsTo pointerAnalysis.PointsTo
+var GlobalPointerAnalysis *pointerAnalysis.Result // The points-to analysis of GlobalProgram, made by InitPreProcess
+
+// builderMode instantiates the generic functions, so each instantiation has a body of its own in which the type
+// parameters are replaced by the type arguments. Calls and method sets then refer to concrete types only.
+const builderMode = ssa.InstantiateGenerics
 
 var ErrNoPackages = errors.New("no packages in the path")
 var ErrLoadPackages = errors.New("loading the following file contained errors")
+var ErrNoMainPackages = errors.New("no main packages match the patterns")
+var ErrNoTests = errors.New("no test functions in the packages matching the patterns")
+
+// EntryPoint is a function the traversal starts from, like main or a TestXxx function, and the main package of the
+// binary it runs in.
+type EntryPoint struct {
+	Main *ssa.Package
+	Func *ssa.Function
+}
 
 func Create(t *testing.T, path, fileName string) *ssa.Package {
 	var conf loader.Config
@@ -36,47 +55,185 @@ func Create(t *testing.T, path, fileName string) *ssa.Package {
 
 	// We needn't call Build.
 	foo := lprog.Package(path).Pkg
-	return ssautil.CreateProgram(lprog, ssa.SanityCheckFunctions).Package(foo)
+	return ssautil.CreateProgram(lprog, ssa.SanityCheckFunctions|builderMode).Package(foo)
+}
+
+// BuildPackage builds the program of a package from its syntax and types, like the ones an analysis pass provides. The
+// packages it imports are created from their types only, so their functions have no bodies.
+func BuildPackage(fset *token.FileSet, pkg *types.Package, files []*ast.File, info *types.Info) (*ssa.Program, *ssa.Package) {
+	ssaProg := ssa.NewProgram(fset, builderMode)
+	isCreated := make(map[*types.Package]bool)
+	var createImports func(pkgs []*types.Package)
+	createImports = func(pkgs []*types.Package) {
+		for _, imported := range pkgs {
+			if isCreated[imported] {
+				continue
+			}
+			isCreated[imported] = true
+			ssaProg.CreatePackage(imported, nil, nil, true)
+			createImports(imported.Imports())
+		}
+	}
+	createImports(pkg.Imports())
+	ssaPkg := ssaProg.CreatePackage(pkg, files, info, false)
+	ssaProg.Build()
+	return ssaProg, ssaPkg
 }
 
 func LoadPackage(path, modulePath string) (*ssa.Program, *ssa.Package, error) {
+	ssaProg, ssaPkg, _, err := loadPackage(path, modulePath, packages.LoadAllSyntax)
+	return ssaProg, ssaPkg, err
+}
+
+// LoadPackageWithModules works the same as LoadPackage, and also returns the paths of the main modules of the program.
+// There are no paths if the package isn't part of a module.
+func LoadPackageWithModules(path, modulePath string) (*ssa.Program, *ssa.Package, []string, error) {
+	return loadPackage(path, modulePath, packages.LoadAllSyntax|packages.NeedModule)
+}
+
+func loadPackage(path, modulePath string, mode packages.LoadMode) (*ssa.Program, *ssa.Package, []string, error) {
 	conf1 := packages.Config{
-		Mode: packages.LoadAllSyntax,
+		Mode: mode,
 		Dir:  modulePath,
 	}
 	loadQuery := fmt.Sprintf("file=%s", path)
 	pkgs, err := packages.Load(&conf1, loadQuery)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	if len(pkgs) == 0 {
-		return nil, nil, fmt.Errorf("%s: %w", path, ErrNoPackages)
+		return nil, nil, nil, fmt.Errorf("%s: %w", path, ErrNoPackages)
 	}
 
 	if len(pkgs[0].Errors) > 0 {
-		return nil, nil, fmt.Errorf("%w %s: %s", ErrLoadPackages, path, pkgs[0].Errors[0].Msg)
+		return nil, nil, nil, fmt.Errorf("%w %s: %s", ErrLoadPackages, path, pkgs[0].Errors[0].Msg)
 	}
-	ssaProg, ssaPkgs := ssautil.AllPackages(pkgs, 0)
+	ssaProg, ssaPkgs := ssautil.AllPackages(pkgs, builderMode)
 	ssaProg.Build()
 	ssaPkg := ssaPkgs[0]
-	return ssaProg, ssaPkg, nil
+	return ssaProg, ssaPkg, getMainModules(pkgs), nil
+}
+
+// LoadPackages loads the packages matching the go list patterns, and returns the main packages among them. All the
+// packages share the same program, so they can be analyzed one after the other and their results merged.
+func LoadPackages(patterns []string, dir string) (*ssa.Program, []*ssa.Package, error) {
+	conf := packages.Config{
+		Mode: packages.LoadAllSyntax,
+		Dir:  dir,
+	}
+	pkgs, err := packages.Load(&conf, patterns...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(pkgs) == 0 {
+		return nil, nil, fmt.Errorf("%s: %w", strings.Join(patterns, " "), ErrNoPackages)
+	}
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			return nil, nil, fmt.Errorf("%w %s: %s", ErrLoadPackages, pkg.PkgPath, pkg.Errors[0].Msg)
+		}
+	}
+	ssaProg, ssaPkgs := ssautil.AllPackages(pkgs, builderMode)
+	ssaProg.Build()
+	mainPkgs := make([]*ssa.Package, 0)
+	for _, ssaPkg := range ssaPkgs {
+		if ssaPkg != nil && ssaPkg.Pkg.Name() == "main" && ssaPkg.Func("main") != nil {
+			mainPkgs = append(mainPkgs, ssaPkg)
+		}
+	}
+	if len(mainPkgs) == 0 {
+		return nil, nil, fmt.Errorf("%s: %w", strings.Join(patterns, " "), ErrNoMainPackages)
+	}
+	return ssaProg, mainPkgs, nil
+}
+
+// LoadTests loads the test binaries of the packages matching the go list patterns, and returns their TestXxx,
+// BenchmarkXxx, FuzzXxx and TestMain functions, ordered by their position.
+func LoadTests(patterns []string, dir string) (*ssa.Program, []EntryPoint, error) {
+	conf := packages.Config{
+		Mode:  packages.LoadAllSyntax,
+		Dir:   dir,
+		Tests: true,
+	}
+	pkgs, err := packages.Load(&conf, patterns...)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			return nil, nil, fmt.Errorf("%w %s: %s", ErrLoadPackages, pkg.ID, pkg.Errors[0].Msg)
+		}
+	}
+	ssaProg, _ := ssautil.AllPackages(pkgs, builderMode)
+	ssaProg.Build()
+
+	entryPoints := make([]EntryPoint, 0)
+	for _, pkg := range pkgs {
+		// The test binary imports the variants of the package that include its test files
+		if pkg.Name != "main" || !strings.HasSuffix(pkg.PkgPath, ".test") {
+			continue
+		}
+		mainPkg := ssaProg.Package(pkg.Types)
+		for _, imported := range pkg.Imports {
+			if !strings.HasSuffix(imported.ID, "["+pkg.PkgPath+"]") {
+				continue
+			}
+			entryPoints = append(entryPoints, getEntryPoints(mainPkg, ssaProg.Package(imported.Types))...)
+		}
+	}
+	if len(entryPoints) == 0 {
+		return nil, nil, fmt.Errorf("%s: %w", strings.Join(patterns, " "), ErrNoTests)
+	}
+	return ssaProg, entryPoints, nil
+}
+
+func getEntryPoints(mainPkg, testPkg *ssa.Package) []EntryPoint {
+	entryPoints := make([]EntryPoint, 0)
+	for _, member := range testPkg.Members {
+		fn, ok := member.(*ssa.Function)
+		if !ok || !ssaPureUtils.IsTestRoot(fn) {
+			continue
+		}
+		if !strings.HasSuffix(testPkg.Prog.Fset.Position(fn.Pos()).Filename, "_test.go") {
+			continue
+		}
+		entryPoints = append(entryPoints, EntryPoint{Main: mainPkg, Func: fn})
+	}
+	sort.Slice(entryPoints, func(i, j int) bool {
+		return entryPoints[i].Func.Pos() < entryPoints[j].Func.Pos()
+	})
+	return entryPoints
 }
 
 func GetStackTrace(prog *ssa.Program, ga *domain.GuardedAccess) string {
 	stack := ""
-	for _, pos := range ga.State.StackTrace.Iter() {
-		calculatedPos := prog.Fset.Position(token.Pos(pos))
+	for _, calculatedPos := range GetStackPositions(prog, ga) {
 		stack += calculatedPos.String()
 		stack += " ->\n"
 	}
 	return stack
 }
 
+// GetStackPositions returns the positions of the calls that lead to the guarded access, starting from the entry point.
+// The positions in the entry points synthesized for libraries are skipped, since their files don't exist.
+func GetStackPositions(prog *ssa.Program, ga *domain.GuardedAccess) []token.Position {
+	positions := make([]token.Position, 0)
+	for _, pos := range ga.State.StackTrace.Iter() {
+		position := prog.Fset.Position(token.Pos(pos))
+		if isHarnessFile(position.Filename) {
+			continue
+		}
+		positions = append(positions, position)
+	}
+	return positions
+}
+
 func GetMethodImplementations(recv types.Type, method *types.Func) []*ssa.Function {
 	methodImplementations := make([]*ssa.Function, 0)
 	recvInterface := recv.(*types.Interface)
 
-	if methodImplementations, ok := typesCache[recvInterface]; ok {
+	key := methodKey{recv: recvInterface, method: method}
+	if methodImplementations, ok := typesCache[key]; ok {
 		return methodImplementations
 	}
 
@@ -90,29 +247,23 @@ func GetMethodImplementations(recv types.Type, method *types.Func) []*ssa.Functi
 		setMethods := GlobalProgram.MethodSets.MethodSet(implementor)
 		method := setMethods.Lookup(method.Pkg(), method.Name())
 		methodImpl := GlobalProgram.MethodValue(method)
-		if methodImpl.Synthetic == "" {
+		if isImplementation(methodImpl) {
 			methodImplementations = append(methodImplementations, methodImpl)
 		}
 	}
 
 	// Sort by pos to enter previous implementations first. This make the search deterministic and easier for debugging
 	sortedImplementations := sortMethodImplementations(methodImplementations)
-	typesCache[recvInterface] = sortedImplementations
+	typesCache[key] = sortedImplementations
 	return sortedImplementations
 }
 
 func sortMethodImplementations(methodImplementations []*ssa.Function) []*ssa.Function {
-	posSlice := make([]int, 0)
-	sortedImplementations := make([]*ssa.Function, 0)
-	implMap := make(map[int]*ssa.Function)
-	for _, methodImplementation := range methodImplementations {
-		pos := methodImplementation.Pos()
-		implMap[int(pos)] = methodImplementation
-		posSlice = append(posSlice, int(pos))
-	}
-	sort.Ints(posSlice)
-	for _, pos := range posSlice {
-		sortedImplementations = append(sortedImplementations, implMap[pos])
-	}
+	sortedImplementations := make([]*ssa.Function, len(methodImplementations))
+	copy(sortedImplementations, methodImplementations)
+	// Instances of the same generic method share its position, so the order of the runtime types is kept between them
+	sort.SliceStable(sortedImplementations, func(i, j int) bool {
+		return sortedImplementations[i].Pos() < sortedImplementations[j].Pos()
+	})
 	return sortedImplementations
 }
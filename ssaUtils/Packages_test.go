@@ -0,0 +1,42 @@
+package ssaUtils
+
+import (
+	"errors"
+	"github.com/pdufour/Chronos/domain"
+	"github.com/pdufour/Chronos/pointerAnalysis"
+	"github.com/pdufour/Chronos/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func Test_LoadPackages_Patterns(t *testing.T) {
+	domain.GoroutineCounter = utils.NewCounter()
+	domain.GuardedAccessCounter = utils.NewCounter()
+	domain.PosIDCounter = utils.NewCounter()
+	_, ex, _, ok := runtime.Caller(0)
+	require.True(t, ok)
+	modulePath := filepath.Dir(filepath.Dir(ex))
+
+	prog, mainPkgs, err := LoadPackages([]string{"./testdata/Packages/Monorepo/..."}, "")
+	require.NoError(t, err)
+	require.Len(t, mainPkgs, 2)
+	InitPreProcess(prog, LoadTestScope(t, modulePath))
+
+	conflictingGAs := make([][]*domain.GuardedAccess, 0)
+	for _, mainPkg := range mainPkgs {
+		conflicts := AnalyzeEntryPoint(mainPkg)
+		require.NotEmpty(t, conflicts)
+		conflictingGAs = append(conflictingGAs, conflicts...)
+	}
+	// Both binaries reach the same race
+	filteredAccesses := pointerAnalysis.FilterDuplicates(conflictingGAs)
+	require.Len(t, filteredAccesses, 1)
+}
+
+func Test_LoadPackages_NoMainPackages(t *testing.T) {
+	_, _, err := LoadPackages([]string{"./testdata/Packages/Monorepo/counter"}, "")
+	assert.True(t, errors.Is(err, ErrNoMainPackages))
+}
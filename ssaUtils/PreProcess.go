@@ -1,13 +1,12 @@
 package ssaUtils
 
 import (
-	"errors"
+	"github.com/pdufour/Chronos/domain"
+	"github.com/pdufour/Chronos/pointerAnalysis"
 	"github.com/pdufour/Chronos/utils/stacks"
 	"go/types"
 	"golang.org/x/tools/go/ssa"
-	"os"
-	"path"
-	"strings"
+	"golang.org/x/tools/go/ssa/ssautil"
 )
 
 type FunctionWithLocksPreprocess struct {
@@ -16,15 +15,42 @@ type FunctionWithLocksPreprocess struct {
 	visitedFuncs      *stacks.FunctionStackWithMap
 }
 
-func InitPreProcess(prog *ssa.Program, defaultModulePath string) error {
+// InitPreProcess prepares the analysis of the program. Only the functions of packages in the scope are analyzed.
+func InitPreProcess(prog *ssa.Program, scope *Scope) {
 	GlobalProgram = prog
-	p := strings.TrimSuffix(defaultModulePath, string(os.PathSeparator))
-	splittedPath := strings.Split(p, string(os.PathSeparator))
-	if len(splittedPath) < 3 {
-		return errors.New("path to module can be relative or absolute but must contain the format:{VCS}/{organization}/{package}")
+	GlobalScope = scope
+	VisitedFunctions = 0
+	initDispatch(prog, GlobalDispatch)
+
+	resetTraversalState()
+	syncValues := make([]ssa.Value, 0)
+	for fn := range ssautil.AllFunctions(prog) {
+		if isModuleFunction(fn) {
+			syncValues = append(syncValues, getSyncValues(fn)...)
+		}
 	}
-	l := len(splittedPath)
-	moduleName := path.Join(splittedPath[l-3], splittedPath[l-2], splittedPath[l-1])
-	GlobalModuleName = moduleName
-	return nil
+	GlobalPointerAnalysis = pointerAnalysis.Analyze(prog)
+	GlobalPointsTo = pointerAnalysis.GetPointsToSets(GlobalPointerAnalysis, syncValues)
+}
+
+// resetTraversalState drops the state recorded by a traversal, so traversals from different entry points don't affect
+// each other. The summaries of the functions are dropped too, since their accesses are bound to the traversal.
+func resetTraversalState() {
+	summariesCache = make(map[summaryKey]*domain.FunctionState)
+	parameterAliases = make(map[ssa.Value]ssa.Value)
+	channelsCache = make(map[pointerAnalysis.Allocation]*domain.Channel)
+	waitGroupsCache = make(map[pointerAnalysis.Allocation]*domain.WaitGroup)
+	oncesCache = make(map[pointerAnalysis.Allocation]*domain.Once)
+}
+
+// AnalyzeEntryPoint traverses the program from the main function of the package, and returns the guarded accesses that
+// conflict with each other. InitPreProcess must be called on the program of the package first.
+func AnalyzeEntryPoint(pkg *ssa.Package) [][]*domain.GuardedAccess {
+	return AnalyzeFunction(pkg.Func("main"))
+}
+
+// AnalyzeFunction traverses the program from the function, like a test function, and returns the guarded accesses that
+// conflict with each other.
+func AnalyzeFunction(entryFunc *ssa.Function) [][]*domain.GuardedAccess {
+	return CheckFunction(entryFunc, []Check{CheckRace}).Conflicts
 }
@@ -0,0 +1,131 @@
+package ssaUtils
+
+import (
+	"errors"
+	"fmt"
+	"go/types"
+	"golang.org/x/tools/go/packages"
+	"regexp"
+	"strings"
+)
+
+var ErrNoModules = errors.New("no main module found for")
+
+// Scope decides which packages are analyzed. A package is analyzed if it belongs to one of the main modules, matches
+// one of the include patterns if there are any, and doesn't match any of the exclude patterns.
+type Scope struct {
+	Modules []string
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// NewScope creates a scope of the modules. Include and exclude patterns are package paths, in which * matches any
+// characters except a slash and ... matches any characters, like in go list patterns.
+func NewScope(modules, include, exclude []string) (*Scope, error) {
+	scope := &Scope{Modules: modules}
+	var err error
+	scope.include, err = compilePackagePatterns(include)
+	if err != nil {
+		return nil, err
+	}
+	scope.exclude, err = compilePackagePatterns(exclude)
+	if err != nil {
+		return nil, err
+	}
+	return scope, nil
+}
+
+func (s *Scope) Contains(pkgPath string) bool {
+	isInModule := false
+	for _, module := range s.Modules {
+		if pkgPath == module || strings.HasPrefix(pkgPath, module+"/") {
+			isInModule = true
+			break
+		}
+	}
+	if !isInModule {
+		return false
+	}
+	if len(s.include) > 0 && !matchAny(s.include, pkgPath) {
+		return false
+	}
+	return !matchAny(s.exclude, pkgPath)
+}
+
+// ContainsPackage works the same as Contains. External test packages, whose name and path end with _test, are
+// contained if the package they test is.
+func (s *Scope) ContainsPackage(pkg *types.Package) bool {
+	pkgPath := pkg.Path()
+	if strings.HasSuffix(pkg.Name(), "_test") {
+		pkgPath = strings.TrimSuffix(pkgPath, "_test")
+	}
+	return s.Contains(pkgPath)
+}
+
+// GetMainModules returns the paths of the main modules of the packages matching the query. When working in a go.work
+// workspace, all the modules of the workspace used by the packages are main modules.
+func GetMainModules(dir string, patterns ...string) ([]string, error) {
+	conf := packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedModule | packages.NeedImports | packages.NeedDeps,
+		Dir:  dir,
+	}
+	pkgs, err := packages.Load(&conf, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	modules := getMainModules(pkgs)
+	if len(modules) == 0 {
+		return nil, fmt.Errorf("%w %s", ErrNoModules, strings.Join(patterns, " "))
+	}
+	return modules, nil
+}
+
+func getMainModules(pkgs []*packages.Package) []string {
+	modules := make([]string, 0)
+	isFound := make(map[string]bool)
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if pkg.Module == nil || !pkg.Module.Main || isFound[pkg.Module.Path] {
+			return
+		}
+		isFound[pkg.Module.Path] = true
+		modules = append(modules, pkg.Module.Path)
+	})
+	return modules
+}
+
+// SplitPatterns splits a comma separated list of patterns.
+func SplitPatterns(list string) []string {
+	patterns := make([]string, 0)
+	for _, pattern := range strings.Split(list, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+func compilePackagePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		expr := regexp.QuoteMeta(pattern)
+		expr = strings.ReplaceAll(expr, `\.\.\.`, `.*`)
+		expr = strings.ReplaceAll(expr, `\*`, `[^/]*`)
+		// Like in go list, a/... matches a itself too
+		expr = strings.ReplaceAll(expr, `/.*`, `(/.*)?`)
+		re, err := regexp.Compile("^" + expr + "$")
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+func matchAny(patterns []*regexp.Regexp, pkgPath string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(pkgPath) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package ssaUtils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go/types"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func Test_Scope_Contains(t *testing.T) {
+	scope, err := NewScope([]string{"example.com/repo"}, nil, []string{"example.com/repo/*/mocks", "example.com/repo/gen/..."})
+	require.NoError(t, err)
+
+	assert.True(t, scope.Contains("example.com/repo"))
+	assert.True(t, scope.Contains("example.com/repo/server"))
+	assert.True(t, scope.Contains("example.com/repo/server/internal/mocks"))
+	assert.False(t, scope.Contains("example.com/repository"))
+	assert.False(t, scope.Contains("github.com/other/repo"))
+	assert.False(t, scope.Contains("example.com/repo/server/mocks"))
+	assert.False(t, scope.Contains("example.com/repo/gen"))
+	assert.False(t, scope.Contains("example.com/repo/gen/proto"))
+}
+
+func Test_Scope_ContainsPackage(t *testing.T) {
+	scope, err := NewScope([]string{"example.com/repo", "example.com/tools_test"}, nil, nil)
+	require.NoError(t, err)
+
+	assert.True(t, scope.ContainsPackage(types.NewPackage("example.com/repo/server", "server")))
+	assert.True(t, scope.ContainsPackage(types.NewPackage("example.com/repo/server_test", "server_test")))
+	assert.True(t, scope.ContainsPackage(types.NewPackage("example.com/tools_test", "tools")))
+	assert.False(t, scope.ContainsPackage(types.NewPackage("example.com/tools", "tools")))
+	assert.False(t, scope.ContainsPackage(types.NewPackage("example.com/other_test", "other_test")))
+}
+
+func Test_Scope_Include(t *testing.T) {
+	scope, err := NewScope([]string{"example.com/repo"}, []string{"example.com/repo/server/..."}, nil)
+	require.NoError(t, err)
+
+	assert.True(t, scope.Contains("example.com/repo/server"))
+	assert.True(t, scope.Contains("example.com/repo/server/handlers"))
+	assert.False(t, scope.Contains("example.com/repo/client"))
+	assert.False(t, scope.Contains("example.com/other/server"))
+}
+
+func Test_GetMainModules(t *testing.T) {
+	_, ex, _, ok := runtime.Caller(0)
+	require.True(t, ok)
+	modulePath := filepath.Dir(filepath.Dir(ex))
+
+	modules, err := GetMainModules(modulePath, "./ssaUtils")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"github.com/pdufour/Chronos"}, modules)
+}
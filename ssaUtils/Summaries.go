@@ -0,0 +1,207 @@
+package ssaUtils
+
+import (
+	"github.com/pdufour/Chronos/domain"
+	"github.com/pdufour/Chronos/pointerAnalysis"
+	"github.com/pdufour/Chronos/utils/stacks"
+	"golang.org/x/tools/go/ssa"
+	"strconv"
+	"strings"
+)
+
+// A summary is the state of a function, computed once and instantiated at each call instead of traversing the
+// function again. Summaries are relative to the function:
+//   - The locksets of the summary only contain the mutexes the function locks and unlocks. The locks held at the
+//     entry of the function are added when the summary is merged into the state of the caller.
+//   - Mutexes the pointer analysis can't tell apart are identified through the parameter they're reached from, and
+//     the argument of the call is used instead when the summary is instantiated.
+//
+// The summary depends on the calling context only by the aliasing of the parameters, since a mutex locked through a
+// parameter and unlocked through another is only released if both parameters point to the same mutex. A function is
+// summarized once per such aliasing. Functions that synchronize with other goroutines or start goroutines aren't
+// summarized, as replaying their accesses in another flow would lose the ordering between the goroutines.
+
+type summaryKey struct {
+	fn      *ssa.Function
+	aliases string // The index of the first parameter that each parameter must alias
+}
+
+// parameterBinding is the value a parameter or a free variable of the called function is bound to by the call.
+type parameterBinding struct {
+	param ssa.Value
+	arg   ssa.Value
+}
+
+var summariesCache = make(map[summaryKey]*domain.FunctionState)
+
+// parameterAliases maps the parameters of the functions being traversed to the first parameter they must alias in the
+// current calling context, so mutexes reached from both are identified the same.
+var parameterAliases = make(map[ssa.Value]ssa.Value)
+
+// handleFunctionCall passes the state of the function called by the call, from its summary if the function was already
+// summarized in the same calling context.
+func handleFunctionCall(context *domain.Context, callCommon *ssa.CallCommon, fn *ssa.Function, done continuation) {
+	bindings := getParameterBindings(callCommon, fn)
+	key := summaryKey{fn: fn, aliases: getAliasesKey(bindings)}
+	if summary, ok := summariesCache[key]; ok {
+		copiedState := summary.Copy() // Copy to avoid override cached item
+		copiedState.AddContextToFunction(context)
+		blockState := domain.CreateBlockState(copiedState.GuardedAccesses, copiedState.Lockset, stacks.NewCallCommonStack())
+		instantiateLocks(blockState, bindings)
+		resume(done, blockState)
+		return
+	}
+
+	restoreAliases := setParameterAliases(bindings)
+	syncEventsBefore := recordedSyncEvents
+	goroutinesBefore := domain.GoroutineCounter.Current()
+	handleFunction(context, fn, func(blockState *domain.BlockState) {
+		restoreAliases()
+		if recordedSyncEvents == syncEventsBefore && domain.GoroutineCounter.Current() == goroutinesBefore {
+			summary := domain.CreateFunctionState(blockState.GuardedAccesses, blockState.Lockset.Copy())
+			summary.RemoveContextFromFunction(context)
+			summariesCache[key] = summary
+		}
+		instantiateLocks(blockState, bindings)
+		resume(done, blockState)
+	})
+}
+
+// getParameterBindings returns the values the call binds to the free variables and the parameters of the function.
+// The receiver of an interface method is the interface value and not the receiver itself, so it isn't bound.
+func getParameterBindings(callCommon *ssa.CallCommon, fn *ssa.Function) []parameterBinding {
+	bindings := make([]parameterBinding, 0, len(fn.FreeVars)+len(fn.Params))
+	if makeClosure, ok := callCommon.Value.(*ssa.MakeClosure); ok && makeClosure.Fn == fn {
+		for i, freeVar := range fn.FreeVars {
+			bindings = append(bindings, parameterBinding{param: freeVar, arg: makeClosure.Bindings[i]})
+		}
+	}
+	params := fn.Params
+	if callCommon.IsInvoke() && len(params) > 0 {
+		params = params[1:]
+	}
+	for i, arg := range callCommon.Args {
+		if i >= len(params) {
+			break
+		}
+		bindings = append(bindings, parameterBinding{param: params[i], arg: arg})
+	}
+	return bindings
+}
+
+// getAliasesKey encodes which of the bound parameters must alias each other. It's empty if none of them do, which is
+// the common case.
+func getAliasesKey(bindings []parameterBinding) string {
+	aliases := getParameterAliases(bindings)
+	if len(aliases) == 0 {
+		return ""
+	}
+	indexes := make([]string, 0, len(bindings))
+	for i, binding := range bindings {
+		index := i
+		if alias, ok := aliases[binding.param]; ok {
+			for j := range bindings {
+				if bindings[j].param == alias {
+					index = j
+				}
+			}
+		}
+		indexes = append(indexes, strconv.Itoa(index))
+	}
+	return strings.Join(indexes, ",")
+}
+
+// getParameterAliases maps each pointer parameter to the first parameter before it that must alias it. Arguments are
+// identified the same way mutexes are, so parameters alias when they would lock the same mutex.
+func getParameterAliases(bindings []parameterBinding) map[ssa.Value]ssa.Value {
+	aliases := make(map[ssa.Value]ssa.Value)
+	firstParams := make(map[domain.LockID]ssa.Value)
+	for _, binding := range bindings {
+		if !pointerAnalysis.CanPoint(binding.param.Type()) {
+			continue
+		}
+		if _, ok := binding.arg.(*ssa.Const); ok { // nil doesn't alias anything
+			continue
+		}
+		key := getLockID(binding.arg, false)
+		if first, ok := firstParams[key]; ok {
+			aliases[binding.param] = first
+		} else {
+			firstParams[key] = binding.param
+		}
+	}
+	return aliases
+}
+
+// setParameterAliases records the aliases between the parameters while the function is traversed, and returns a
+// function restoring the previous aliases when the traversal of the function is done.
+func setParameterAliases(bindings []parameterBinding) func() {
+	aliases := getParameterAliases(bindings)
+	previous := make(map[ssa.Value]ssa.Value)
+	for _, binding := range bindings {
+		if alias, ok := parameterAliases[binding.param]; ok {
+			previous[binding.param] = alias
+		}
+		delete(parameterAliases, binding.param)
+	}
+	for param, alias := range aliases {
+		parameterAliases[param] = alias
+	}
+	return func() {
+		for _, binding := range bindings {
+			delete(parameterAliases, binding.param)
+		}
+		for param, alias := range previous {
+			parameterAliases[param] = alias
+		}
+	}
+}
+
+// instantiateLocks replaces the mutexes identified through the parameters of the function by the arguments of the call.
+func instantiateLocks(blockState *domain.BlockState, bindings []parameterBinding) {
+	args := make(map[ssa.Value]ssa.Value, len(bindings))
+	for _, binding := range bindings {
+		args[binding.param] = binding.arg
+	}
+	if len(args) == 0 {
+		return
+	}
+	instantiateLockset(blockState.Lockset, args)
+	for _, ga := range blockState.GuardedAccesses {
+		instantiateLockset(ga.Lockset, args)
+		if ga.OpKind == domain.GuardAccessLock {
+			ga.Mutex = instantiateLockID(ga.Mutex, args)
+		}
+	}
+}
+
+func instantiateLockset(lockset *domain.Lockset, args map[ssa.Value]ssa.Value) {
+	lockset.Locks = instantiateLocksLastUse(lockset.Locks, args)
+	lockset.Unlocks = instantiateLocksLastUse(lockset.Unlocks, args)
+}
+
+func instantiateLocksLastUse(locks map[domain.LockID]*ssa.CallCommon, args map[ssa.Value]ssa.Value) map[domain.LockID]*ssa.CallCommon {
+	isInstantiated := false
+	for lockID := range locks {
+		if _, ok := args[lockID.Site]; ok {
+			isInstantiated = true
+		}
+	}
+	if !isInstantiated {
+		return locks
+	}
+	instantiated := make(map[domain.LockID]*ssa.CallCommon, len(locks))
+	for lockID, call := range locks {
+		instantiated[instantiateLockID(lockID, args)] = call
+	}
+	return instantiated
+}
+
+func instantiateLockID(lockID domain.LockID, args map[ssa.Value]ssa.Value) domain.LockID {
+	arg, ok := args[lockID.Site]
+	if !ok {
+		return lockID
+	}
+	argID := getLockID(arg, lockID.IsRead)
+	return domain.LockID{Site: argID.Site, Path: argID.Path + lockID.Path, IsRead: lockID.IsRead}
+}
@@ -0,0 +1,63 @@
+package ssaUtils
+
+import (
+	"github.com/pdufour/Chronos/domain"
+	"github.com/pdufour/Chronos/pointerAnalysis"
+	"github.com/pdufour/Chronos/ssaPureUtils"
+	"golang.org/x/tools/go/ssa"
+)
+
+// recordedSyncEvents counts the synchronization events recorded so far. Functions that record events are not cached,
+// since replaying their guarded accesses would drop the synchronization.
+var recordedSyncEvents int
+
+func newSyncEvent(context *domain.Context, isBlocking bool) *domain.SyncEvent {
+	recordedSyncEvents++
+	return domain.NewSyncEvent(context, isBlocking)
+}
+
+// GetAllocations returns the allocations the value may point to. If the value wasn't part of the pointer analysis, the
+// value itself is used as the allocation site.
+func GetAllocations(value ssa.Value) []pointerAnalysis.Allocation {
+	if allocs, ok := GlobalPointsTo[value]; ok {
+		return allocs
+	}
+	return []pointerAnalysis.Allocation{{Site: value}}
+}
+
+// getSyncValues returns the values used to access synchronization objects in the function, to identify the objects
+// using pointer analysis.
+func getSyncValues(fn *ssa.Function) []ssa.Value {
+	values := make([]ssa.Value, 0)
+	for _, block := range fn.Blocks {
+		for _, ins := range block.Instrs {
+			switch call := ins.(type) {
+			case *ssa.Send:
+				values = append(values, call.Chan)
+			case *ssa.UnOp:
+				if ssaPureUtils.IsReceive(call) {
+					values = append(values, call.X)
+				}
+			case *ssa.Select:
+				for _, state := range call.States {
+					values = append(values, state.Chan)
+				}
+			case ssa.CallInstruction:
+				callCommon := call.Common()
+				switch callee := callCommon.Value.(type) {
+				case *ssa.Builtin:
+					if callee.Name() == "close" {
+						values = append(values, callCommon.Args[0])
+					}
+				case *ssa.Function:
+					if ssaPureUtils.IsWaitGroupDone(callee) || ssaPureUtils.IsWaitGroupWait(callee) || ssaPureUtils.IsOnceDo(callee) ||
+						ssaPureUtils.IsLock(callee) || ssaPureUtils.IsUnlock(callee) || ssaPureUtils.IsRLock(callee) ||
+						ssaPureUtils.IsRUnlock(callee) {
+						values = append(values, callCommon.Args[0])
+					}
+				}
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,108 @@
+package ssaUtils
+
+import (
+	"github.com/pdufour/Chronos/domain"
+	"github.com/pdufour/Chronos/utils/stacks"
+	"golang.org/x/tools/go/ssa"
+)
+
+// handleTestRun records a call to t.Run. The subtest runs before t.Run returns, like a regular call, up to its call to
+// t.Parallel if it makes one. The caller continues from that point, and the rest of the subtest runs in a goroutine of
+// its own, so parallel subtests run concurrently with each other.
+func handleTestRun(context *domain.Context, call *ssa.CallCommon, done continuation) {
+	handleSubtest(context, call.Args[2], []ssa.Value{call.Args[0]}, done)
+}
+
+func handleSubtest(context *domain.Context, subtest ssa.Value, args []ssa.Value, done continuation) {
+	test := domain.NewTest(context.Test)
+	subtestContext := context.Copy()
+	subtestContext.Test = test
+	subtestCall := &ssa.CallCommon{Value: subtest, Args: args}
+	pos := subtest.Pos()
+	if closure, ok := subtest.(*ssa.MakeClosure); ok { // A closure has no position, and nested subtests would seem recursive
+		pos = closure.Fn.Pos()
+	}
+	handleCallCommon(subtestContext, subtestCall, pos, func(funcState *domain.BlockState) {
+		completeTest(subtestContext, test)
+		if !test.IsParallel() {
+			context.Clock, context.LastAcquire = subtestContext.Clock, subtestContext.LastAcquire
+			resume(done, funcState)
+			return
+		}
+		context.Clock, context.LastAcquire = test.Paused.Clock, test.Paused.LastAcquire
+		resume(done, getGoroutinesState(funcState))
+	})
+}
+
+// handleTestParallel records a call to t.Parallel. The flow of the subtest turns into a goroutine of its own, which
+// resumes after the function of the parent test returned.
+func handleTestParallel(context *domain.Context) {
+	test := context.Test
+	if test == nil || test.Parent == nil || test.IsParallel() { // A top level test runs alone, unless grouped by CheckParallelTests
+		return
+	}
+	parallelContext := domain.NewGoroutineExecutionState(context)
+	test.Paused = context.Copy()
+	context.GoroutineID, context.Clock = parallelContext.GoroutineID, parallelContext.Clock
+	event := newSyncEvent(context, true)
+	event.AddOperation(test.Parent, domain.SyncOpTestResume)
+	context.Acquire(event)
+}
+
+// completeTest records the return of the test function, and the completion of the test once its parallel subtests
+// completed. The completion of a parallel subtest is released to its parent test.
+func completeTest(context *domain.Context, test *domain.Test) {
+	returnEvent := newSyncEvent(context, false)
+	returnEvent.AddOperation(test, domain.SyncOpTestReturn)
+	context.Acquire(returnEvent)
+	waitEvent := newSyncEvent(context, true)
+	waitEvent.AddOperation(test, domain.SyncOpTestWait)
+	context.Acquire(waitEvent)
+	if test.IsParallel() {
+		endEvent := newSyncEvent(context, false)
+		endEvent.AddOperation(test.Parent, domain.SyncOpTestEnd)
+		context.Acquire(endEvent)
+	}
+}
+
+// handleRunParallel records a call to b.RunParallel, which runs the body in several goroutines. Two goroutines are
+// enough to find the races between them.
+func handleRunParallel(context *domain.Context, call *ssa.CallCommon, done continuation) {
+	handleParallelBodies(context, call.Args[1], 2, domain.GetEmptyBlockState(), done)
+}
+
+func handleParallelBodies(context *domain.Context, body ssa.Value, count int, funcState *domain.BlockState, done continuation) {
+	if count == 0 {
+		resume(done, getGoroutinesState(funcState))
+		return
+	}
+	newState := domain.NewGoroutineExecutionState(context)
+	handleCallCommon(newState, &ssa.CallCommon{Value: body}, body.Pos(), func(funcStateRet *domain.BlockState) {
+		funcState.AddFunctionCallState(funcStateRet, false)
+		handleParallelBodies(context, body, count-1, funcState, done)
+	})
+}
+
+// handleTests records the run of top level tests one after the other, as subtests of the test binary.
+func handleTests(context *domain.Context, tests []*ssa.Function, funcState *domain.BlockState, done continuation) {
+	if len(tests) == 0 {
+		resume(done, funcState)
+		return
+	}
+	handleSubtest(context, tests[0], nil, func(testState *domain.BlockState) {
+		funcState.AddFunctionCallState(testState, false)
+		handleTests(context, tests[1:], funcState, done)
+	})
+}
+
+// handleFuzz records a call to f.Fuzz. The fuzz target runs for each input one after the other.
+func handleFuzz(context *domain.Context, call *ssa.CallCommon, done continuation) {
+	target := call.Args[1]
+	handleCallCommon(context, &ssa.CallCommon{Value: target}, target.Pos(), done)
+}
+
+// getGoroutinesState returns the accesses of goroutines started by a call, without the locks they hold, since the
+// locks of other goroutines don't protect the caller.
+func getGoroutinesState(funcState *domain.BlockState) *domain.BlockState {
+	return domain.CreateBlockState(funcState.GuardedAccesses, domain.NewLockset(), stacks.NewCallCommonStack())
+}
@@ -0,0 +1,58 @@
+package ssaUtils
+
+import (
+	"github.com/pdufour/Chronos/domain"
+	"github.com/pdufour/Chronos/pointerAnalysis"
+	"github.com/pdufour/Chronos/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func Test_LoadTests_Subtests(t *testing.T) {
+	domain.GoroutineCounter = utils.NewCounter()
+	domain.GuardedAccessCounter = utils.NewCounter()
+	domain.PosIDCounter = utils.NewCounter()
+	_, ex, _, ok := runtime.Caller(0)
+	require.True(t, ok)
+	modulePath := filepath.Dir(filepath.Dir(ex))
+
+	prog, entryPoints, err := LoadTests([]string{"./testdata/Tests/parallel"}, "")
+	require.NoError(t, err)
+	InitPreProcess(prog, LoadTestScope(t, modulePath))
+
+	conflictsByTest := make(map[string]int)
+	for _, entryPoint := range entryPoints {
+		conflictingGAs := AnalyzeFunction(entryPoint.Func)
+		conflictsByTest[entryPoint.Func.Name()] = len(pointerAnalysis.FilterDuplicates(conflictingGAs))
+	}
+	expected := map[string]int{
+		"TestParallelSubtests":    1,
+		"TestSequentialSubtests":  0,
+		"BenchmarkInc":            1,
+		"TestWriteBeforeParallel": 0,
+		"TestReadAfterParallel":   0,
+		"TestParallelGroup":       1,
+	}
+	assert.Equal(t, expected, conflictsByTest)
+}
+
+func Test_LoadTests_ParallelTests(t *testing.T) {
+	domain.GoroutineCounter = utils.NewCounter()
+	domain.GuardedAccessCounter = utils.NewCounter()
+	domain.PosIDCounter = utils.NewCounter()
+	_, ex, _, ok := runtime.Caller(0)
+	require.True(t, ok)
+	modulePath := filepath.Dir(filepath.Dir(ex))
+
+	prog, entryPoints, err := LoadTests([]string{"./testdata/Tests/toplevel"}, "")
+	require.NoError(t, err)
+	InitPreProcess(prog, LoadTestScope(t, modulePath))
+
+	findings := CheckEntryPoints(entryPoints, []Check{CheckRace})
+	conflictingGAs := pointerAnalysis.FilterDuplicates(findings.Conflicts)
+	require.Len(t, conflictingGAs, 1)
+	assert.ElementsMatch(t, [][2]int{{7, 17}}, GetConflictLines(prog, conflictingGAs))
+}
@@ -0,0 +1,71 @@
+package ssaUtils
+
+import (
+	"github.com/pdufour/Chronos/domain"
+)
+
+// The traversal of the program is iterative, so deep calls and long control flow graphs don't overflow the stack of the
+// goroutine. Each handler that traverses calls or blocks gets a continuation instead of returning the state, and the
+// work left after a call is scheduled as a task, to be run once the state of the call is known. The tasks are kept in a
+// stack, so they run depth first in the same order a recursive traversal would, and each task does a bounded amount of
+// work before it schedules the next one. The state of the traversal lives in the closures of the pending continuations,
+// which grow with the depth of the calls only.
+//
+// To keep each task bounded, continuations are always called through resume, and the body of a function is traversed
+// in a task of its own.
+
+// continuation receives the state computed by a handler.
+type continuation func(state *domain.BlockState)
+
+var tasks = make([]func(), 0)
+
+func schedule(task func()) {
+	tasks = append(tasks, task)
+}
+
+// resume passes the state to the continuation in a task of its own.
+func resume(done continuation, state *domain.BlockState) {
+	schedule(func() {
+		done(state)
+	})
+}
+
+// runTraversal runs the handler and the tasks it schedules, and returns the state the handler completed with. It may be
+// called from a task, and then only runs the tasks scheduled after it was called.
+func runTraversal(handle func(done continuation)) *domain.BlockState {
+	base := len(tasks)
+	var result *domain.BlockState
+	handle(func(state *domain.BlockState) {
+		result = state
+	})
+	for len(tasks) > base {
+		task := tasks[len(tasks)-1]
+		tasks = tasks[:len(tasks)-1]
+		task()
+	}
+	return result
+}
+
+// handleSiblings handles the alternatives one after the other, and merges their states as siblings, since only one of
+// them runs. It's used for the implementations an interface method call or a function value may call.
+func handleSiblings(count int, handle func(i int, done continuation), done continuation) {
+	mergeSiblings(0, count, nil, handle, done)
+}
+
+func mergeSiblings(i, count int, merged *domain.BlockState, handle func(i int, done continuation), done continuation) {
+	if i == count {
+		if merged == nil {
+			merged = domain.GetEmptyBlockState()
+		}
+		resume(done, merged)
+		return
+	}
+	handle(i, func(state *domain.BlockState) {
+		if merged == nil {
+			merged = state
+		} else {
+			merged.MergeSiblingBlock(state)
+		}
+		mergeSiblings(i+1, count, merged, handle, done)
+	})
+}
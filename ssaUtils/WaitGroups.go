@@ -0,0 +1,32 @@
+package ssaUtils
+
+import (
+	"github.com/pdufour/Chronos/domain"
+	"github.com/pdufour/Chronos/pointerAnalysis"
+	"golang.org/x/tools/go/ssa"
+)
+
+var waitGroupsCache = make(map[pointerAnalysis.Allocation]*domain.WaitGroup)
+
+func HandleWaitGroupDone(context *domain.Context, call *ssa.CallCommon) {
+	event := newSyncEvent(context, true)
+	addWaitGroupOperation(event, call.Args[0], domain.SyncOpDone)
+	context.Acquire(event)
+}
+
+func HandleWaitGroupWait(context *domain.Context, call *ssa.CallCommon) {
+	event := newSyncEvent(context, true)
+	addWaitGroupOperation(event, call.Args[0], domain.SyncOpWait)
+	context.Acquire(event)
+}
+
+func addWaitGroupOperation(event *domain.SyncEvent, waitGroup ssa.Value, kind domain.SyncOpKind) {
+	for _, alloc := range GetAllocations(waitGroup) {
+		wg, ok := waitGroupsCache[alloc]
+		if !ok {
+			wg = domain.NewWaitGroup()
+			waitGroupsCache[alloc] = wg
+		}
+		event.AddOperation(wg, kind)
+	}
+}
@@ -0,0 +1,12 @@
+package main
+
+import "sync/atomic"
+
+func main() {
+	var x int64
+	go func() {
+		atomic.AddInt64(&x, 1)
+	}()
+	atomic.StoreInt64(&x, 2)
+	_ = atomic.LoadInt64(&x)
+}
@@ -0,0 +1,11 @@
+package main
+
+import "sync/atomic"
+
+func main() {
+	var x int64
+	go func() {
+		atomic.AddInt64(&x, 1)
+	}()
+	_ = x
+}
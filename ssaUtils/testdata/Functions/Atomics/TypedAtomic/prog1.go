@@ -0,0 +1,19 @@
+package main
+
+import "sync/atomic"
+
+type Counter struct {
+	count atomic.Int64
+	ready atomic.Bool
+}
+
+func main() {
+	c := &Counter{}
+	go func() {
+		c.count.Add(1)
+		c.ready.Store(true)
+	}()
+	if c.ready.Load() {
+		_ = c.count.Load()
+	}
+}
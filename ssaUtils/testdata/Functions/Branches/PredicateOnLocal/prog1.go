@@ -0,0 +1,22 @@
+package main
+
+import "os"
+
+func main() {
+	done := make(chan bool)
+	count := 0
+	go func() {
+		count = 1
+		done <- true
+	}()
+	n := len(os.Args)
+	if n == 1 {
+		if n != 1 {
+			count = 2 // Never runs, since n is 1 in this branch.
+		}
+	} else {
+		count = 3
+	}
+	<-done
+	os.Exit(count)
+}
@@ -0,0 +1,14 @@
+package main
+
+func main() {
+	done := make(chan bool)
+	debug := false
+	count := 0
+	go func() {
+		count = 1
+		done <- true
+	}()
+	if !debug || count > 0 { // The second operand never runs.
+	}
+	<-done
+}
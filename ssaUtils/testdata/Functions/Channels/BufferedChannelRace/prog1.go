@@ -0,0 +1,13 @@
+package main
+
+func main() {
+	ch := make(chan bool, 1)
+	x := 0
+	_ = x
+	go func() {
+		<-ch
+		x = 1
+	}()
+	ch <- true
+	x = 2
+}
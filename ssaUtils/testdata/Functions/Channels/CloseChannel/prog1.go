@@ -0,0 +1,13 @@
+package main
+
+func main() {
+	done := make(chan struct{})
+	x := 0
+	_ = x
+	go func() {
+		defer close(done)
+		x = 1
+	}()
+	<-done
+	x = 2
+}
@@ -0,0 +1,16 @@
+package main
+
+func main() {
+	ch := make(chan bool, 1)
+	x := 0
+	_ = x
+	go func() {
+		x = 1
+		ch <- true
+	}()
+	select {
+	case <-ch:
+	default:
+	}
+	x = 2
+}
@@ -0,0 +1,13 @@
+package main
+
+func main() {
+	ch := make(chan bool)
+	x := 0
+	_ = x
+	go func() {
+		<-ch
+		x = 1
+	}()
+	x = 2
+	ch <- true
+}
@@ -0,0 +1,13 @@
+package main
+
+func main() {
+	ch := make(chan bool)
+	x := 0
+	_ = x
+	go func() {
+		x = 1
+		ch <- true
+	}()
+	<-ch
+	x = 2
+}
@@ -0,0 +1,24 @@
+package main
+
+import "sync"
+
+func main() {
+	var a, b, gate sync.Mutex
+	done := make(chan bool)
+	go func() {
+		gate.Lock()
+		a.Lock()
+		b.Lock()
+		b.Unlock()
+		a.Unlock()
+		gate.Unlock()
+		done <- true
+	}()
+	gate.Lock() // Both orders are taken while holding the gate, so they never run together.
+	b.Lock()
+	a.Lock()
+	a.Unlock()
+	b.Unlock()
+	gate.Unlock()
+	<-done
+}
@@ -0,0 +1,21 @@
+package main
+
+import "sync"
+
+func lockBoth(x, y *sync.Mutex) {
+	x.Lock()
+	y.Lock()
+	y.Unlock()
+	x.Unlock()
+}
+
+func main() {
+	var a, b sync.Mutex
+	done := make(chan bool)
+	go func() {
+		lockBoth(&a, &b)
+		done <- true
+	}()
+	lockBoth(&b, &a)
+	<-done
+}
@@ -0,0 +1,20 @@
+package main
+
+import "sync"
+
+func main() {
+	var a, b sync.Mutex
+	done := make(chan bool)
+	go func() {
+		a.Lock()
+		b.Lock()
+		b.Unlock()
+		a.Unlock()
+		done <- true
+	}()
+	b.Lock()
+	a.Lock() // Acquires a while holding b, while the goroutine acquires b while holding a.
+	a.Unlock()
+	b.Unlock()
+	<-done
+}
@@ -0,0 +1,20 @@
+package main
+
+import "sync"
+
+func main() {
+	var a, b sync.Mutex
+	done := make(chan bool)
+	go func() {
+		a.Lock()
+		b.Lock()
+		b.Unlock()
+		a.Unlock()
+		done <- true
+	}()
+	<-done
+	b.Lock() // The goroutine is done before the mutexes are acquired in the other order.
+	a.Lock()
+	a.Unlock()
+	b.Unlock()
+}
@@ -0,0 +1,20 @@
+package main
+
+import "sync"
+
+func main() {
+	var a, b sync.RWMutex
+	done := make(chan bool)
+	go func() {
+		a.RLock()
+		b.RLock()
+		b.RUnlock()
+		a.RUnlock()
+		done <- true
+	}()
+	b.RLock() // Read locks don't wait for each other.
+	a.RLock()
+	a.RUnlock()
+	b.RUnlock()
+	<-done
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+type Counter interface {
+	Inc()
+}
+
+type SafeCounter struct {
+	mu    sync.Mutex
+	count int
+}
+
+func (c *SafeCounter) Inc() {
+	c.mu.Lock()
+	c.count++
+	c.mu.Unlock()
+}
+
+type UnsafeCounter struct {
+	count int
+}
+
+func (c *UnsafeCounter) Inc() {
+	c.count++
+}
+
+func run(c Counter) {
+	c.Inc()
+}
+
+func main() {
+	// The unsafe counter is only printed, so it never reaches run
+	fmt.Println(&UnsafeCounter{})
+	c := &SafeCounter{}
+	go run(c)
+	run(c)
+}
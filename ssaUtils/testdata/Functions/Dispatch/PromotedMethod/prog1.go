@@ -0,0 +1,23 @@
+package main
+
+var x int
+
+type Incrementer interface {
+	Inc()
+}
+
+type T struct{}
+
+func (*T) Inc() {
+	x++ // Called through the wrapper of the method promoted to U.
+}
+
+type U struct {
+	*T
+}
+
+func main() {
+	var i Incrementer = U{&T{}}
+	go i.Inc()
+	i.Inc()
+}
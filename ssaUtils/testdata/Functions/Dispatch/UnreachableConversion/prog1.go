@@ -0,0 +1,41 @@
+package main
+
+import "sync"
+
+type Counter interface {
+	Inc()
+}
+
+type SafeCounter struct {
+	mu    sync.Mutex
+	count int
+}
+
+func (c *SafeCounter) Inc() {
+	c.mu.Lock()
+	c.count++
+	c.mu.Unlock()
+}
+
+type UnsafeCounter struct {
+	count int
+}
+
+func (c *UnsafeCounter) Inc() {
+	c.count++
+}
+
+func run(c Counter) {
+	c.Inc()
+}
+
+// Never called, but makes UnsafeCounter a runtime type of the program
+func newUnsafe() Counter {
+	return &UnsafeCounter{}
+}
+
+func main() {
+	c := &SafeCounter{}
+	go run(c)
+	run(c)
+}
@@ -0,0 +1,19 @@
+package main
+
+var x int
+
+type Incrementer interface {
+	Inc()
+}
+
+type T struct{}
+
+func (T) Inc() {
+	x++ // Called through the wrapper of the method in the method set of *T.
+}
+
+func main() {
+	var i Incrementer = &T{}
+	go i.Inc()
+	i.Inc()
+}
@@ -0,0 +1,18 @@
+package main
+
+import "sync"
+
+var mu sync.Mutex
+var count int
+
+func inc(lock, unlock func()) {
+	lock()
+	count++ // The bound methods lock the same mutex.
+	unlock()
+}
+
+func main() {
+	lock, unlock := mu.Lock, mu.Unlock
+	go inc(lock, unlock)
+	inc(lock, unlock)
+}
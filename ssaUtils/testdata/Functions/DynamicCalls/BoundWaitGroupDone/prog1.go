@@ -0,0 +1,16 @@
+package main
+
+import "sync"
+
+func main() {
+	var wg sync.WaitGroup
+	count := 0
+	done := wg.Done
+	wg.Add(1)
+	go func() {
+		count++ // The bound Done happens-before the return of Wait.
+		done()
+	}()
+	wg.Wait()
+	count++
+}
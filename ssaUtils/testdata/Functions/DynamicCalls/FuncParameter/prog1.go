@@ -0,0 +1,15 @@
+package main
+
+var count int
+
+func apply(f func()) {
+	f()
+}
+
+func main() {
+	inc := func() {
+		count++
+	}
+	go apply(inc)
+	apply(inc)
+}
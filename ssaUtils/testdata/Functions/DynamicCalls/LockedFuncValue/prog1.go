@@ -0,0 +1,20 @@
+package main
+
+import "sync"
+
+var mu sync.Mutex
+var count int
+
+func withLock(f func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	f()
+}
+
+func main() {
+	inc := func() {
+		count++
+	}
+	go withLock(inc)
+	withLock(inc)
+}
@@ -0,0 +1,13 @@
+package main
+
+var count int
+
+func main() {
+	handlers := map[string]func(){
+		"inc": func() {
+			count++
+		},
+	}
+	go handlers["inc"]()
+	handlers["inc"]()
+}
@@ -0,0 +1,18 @@
+package main
+
+import "sync"
+
+var count int
+
+func main() {
+	var mu sync.Mutex
+	lock, unlock := (*sync.Mutex).Lock, (*sync.Mutex).Unlock
+	go func() {
+		lock(&mu)
+		count++
+		unlock(&mu)
+	}()
+	lock(&mu)
+	count++
+	unlock(&mu)
+}
@@ -0,0 +1,15 @@
+package main
+
+func newCounter() func() int {
+	count := 0
+	return func() int {
+		count++
+		return count
+	}
+}
+
+func main() {
+	next := newCounter()
+	go next()
+	next()
+}
@@ -0,0 +1,17 @@
+package main
+
+type Worker struct {
+	run func()
+}
+
+var count int
+
+func work() {
+	count++
+}
+
+func main() {
+	worker := &Worker{run: work}
+	go worker.run()
+	worker.run()
+}
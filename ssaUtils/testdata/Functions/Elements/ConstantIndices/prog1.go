@@ -0,0 +1,12 @@
+package main
+
+func main() {
+	done := make(chan bool)
+	values := make([]int, 3)
+	go func() {
+		values[0] = 1
+		done <- true
+	}()
+	values[2] = values[1] // Different elements than the goroutine accesses.
+	<-done
+}
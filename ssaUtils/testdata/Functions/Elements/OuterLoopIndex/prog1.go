@@ -0,0 +1,12 @@
+package main
+
+func main() {
+	out := make([]int, 10)
+	for j := 0; j < 10; j++ {
+		for i := 0; i < 10; i++ {
+			go func(j int) {
+				out[j]++ // The goroutines of the same outer iteration increment the same element.
+			}(j)
+		}
+	}
+}
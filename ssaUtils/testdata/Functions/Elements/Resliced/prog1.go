@@ -0,0 +1,13 @@
+package main
+
+func main() {
+	done := make(chan bool)
+	values := make([]int, 3)
+	tail := values[1:]
+	go func() {
+		values[1] = 1
+		done <- true
+	}()
+	tail[0] = 2 // The same element as values[1].
+	<-done
+}
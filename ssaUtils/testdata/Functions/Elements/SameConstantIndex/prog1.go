@@ -0,0 +1,12 @@
+package main
+
+func main() {
+	done := make(chan bool)
+	values := make([]int, 3)
+	go func() {
+		values[1] = 1
+		done <- true
+	}()
+	values[1] = 2 // The same element as the goroutine writes.
+	<-done
+}
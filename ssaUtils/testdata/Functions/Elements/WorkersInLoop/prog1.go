@@ -0,0 +1,17 @@
+package main
+
+import "sync"
+
+func main() {
+	var wg sync.WaitGroup
+	out := make([]int, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			out[i] = out[i] * 2 // Each worker accesses the element of its own iteration.
+			out[0]++            // But all of them access the first element.
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,15 @@
+package main
+
+func count(words []string) int {
+	counts := make(map[string]int) // Each goroutine allocates a map of its own.
+	for _, word := range words {
+		counts[word]++
+	}
+	return len(counts)
+}
+
+func main() {
+	words := []string{"a", "b", "a"}
+	go count(words)
+	count(words)
+}
@@ -0,0 +1,16 @@
+package main
+
+type result struct {
+	value int
+}
+
+func main() {
+	results := make(chan *result, 1)
+	go func() {
+		r := &result{}
+		results <- r
+		r.value = 1 // The result was already sent to the main goroutine.
+	}()
+	r := <-results
+	_ = r.value
+}
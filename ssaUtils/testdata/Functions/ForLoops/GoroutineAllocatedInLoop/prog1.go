@@ -0,0 +1,11 @@
+package main
+
+func main() {
+	for i := 0; i < 10; i++ {
+		p := new(int)
+		*p = i // Each iteration allocates its own integer, so the goroutines of other iterations don't read it.
+		go func() {
+			println(*p)
+		}()
+	}
+}
@@ -0,0 +1,16 @@
+package main
+
+import "sync"
+
+func main() {
+	var wg sync.WaitGroup
+	count := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			count++ // The goroutines of the different iterations race with each other.
+		}()
+	}
+	wg.Wait()
+}
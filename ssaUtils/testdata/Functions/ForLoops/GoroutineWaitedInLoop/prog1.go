@@ -0,0 +1,13 @@
+package main
+
+func main() {
+	x := new(int)
+	done := make(chan bool)
+	for i := 0; i < 10; i++ {
+		go func() {
+			*x = 1 // The goroutine of the previous iteration completed before this one starts.
+			done <- true
+		}()
+		<-done
+	}
+}
@@ -0,0 +1,16 @@
+package main
+
+import "sync"
+
+func main() {
+	m := make(map[string]string)
+	mutex := sync.Mutex{}
+	mutex.Lock()
+	for range m {
+		m["1"] = "a" // The mutex is locked before the loop and again at the end of each iteration.
+		mutex.Unlock()
+		m["2"] = "b"
+		mutex.Lock()
+	}
+	mutex.Unlock()
+}
@@ -0,0 +1,13 @@
+package main
+
+import "sync"
+
+func main() {
+	m := make(map[string]string)
+	mutex := sync.Mutex{}
+	mutex.Lock()
+	for range m {
+		m["1"] = "a" // Only the first iteration holds the mutex.
+		mutex.Unlock()
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import "sync"
+
+type Cache[K comparable, V any] struct {
+	mu    sync.Mutex
+	items map[K]V
+}
+
+func NewCache[K comparable, V any]() *Cache[K, V] {
+	return &Cache[K, V]{items: make(map[K]V)}
+}
+
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.items[key]
+	return value, ok
+}
+
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[key] = value
+}
+
+func main() {
+	names := NewCache[int, string]()
+	ages := NewCache[string, int]()
+	go func() {
+		names.Set(1, "a")
+		ages.Set("a", 1)
+	}()
+	names.Get(1)
+	ages.Get("a")
+}
@@ -0,0 +1,30 @@
+package main
+
+import "sync"
+
+type Cache[K comparable, V any] struct {
+	mu    sync.Mutex
+	items map[K]V
+}
+
+func NewCache[K comparable, V any]() *Cache[K, V] {
+	return &Cache[K, V]{items: make(map[K]V)}
+}
+
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[key] = value
+}
+
+func (c *Cache[K, V]) Len() int {
+	return len(c.items)
+}
+
+func main() {
+	cache := NewCache[string, int]()
+	go func() {
+		cache.Set("a", 1)
+	}()
+	_ = cache.Len()
+}
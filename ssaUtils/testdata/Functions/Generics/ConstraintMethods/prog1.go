@@ -0,0 +1,41 @@
+package main
+
+import "sync"
+
+type Incrementer interface {
+	Inc()
+}
+
+type LockedCounter struct {
+	mu    sync.Mutex
+	value int
+}
+
+func (c *LockedCounter) Inc() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.value++
+}
+
+type Counter struct {
+	value int
+}
+
+func (c *Counter) Inc() {
+	c.value++
+}
+
+func inc[T Incrementer](counter T) {
+	counter.Inc()
+}
+
+func main() {
+	locked := &LockedCounter{}
+	racy := &Counter{}
+	go func() {
+		inc(locked)
+		inc(racy)
+	}()
+	inc(locked)
+	inc(racy)
+}
@@ -0,0 +1,27 @@
+package main
+
+import "sync"
+
+type Box[T any] struct {
+	value T
+}
+
+func (b *Box[T]) Store(value T) {
+	b.value = value
+}
+
+func main() {
+	var mu sync.Mutex
+	ints := &Box[int]{}
+	strs := &Box[string]{}
+	go func() {
+		mu.Lock()
+		ints.Store(1)
+		mu.Unlock()
+		strs.Store("a")
+	}()
+	mu.Lock()
+	ints.Store(2)
+	mu.Unlock()
+	strs.Store("b")
+}
@@ -0,0 +1,41 @@
+package main
+
+import "sync"
+
+type Store[V any] interface {
+	Put(value V)
+}
+
+type SliceStore[V any] struct {
+	items []V
+}
+
+func (s *SliceStore[V]) Put(value V) {
+	s.items = append(s.items, value)
+}
+
+type LockedStore[V any] struct {
+	mu    sync.Mutex
+	items []V
+}
+
+func (s *LockedStore[V]) Put(value V) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = append(s.items, value)
+}
+
+func fill[V any](store Store[V], value V) {
+	store.Put(value)
+}
+
+func main() {
+	var racy Store[int] = &SliceStore[int]{}
+	var locked Store[string] = &LockedStore[string]{}
+	go func() {
+		fill(racy, 1)
+		fill(locked, "a")
+	}()
+	fill(racy, 2)
+	fill(locked, "b")
+}
@@ -0,0 +1,19 @@
+package main
+
+func load[T any](p *T, _ T) T {
+	return *p
+}
+
+func store[T any](p *T, value T) T {
+	*p = value
+	return value
+}
+
+func main() {
+	x := 0
+	go func() {
+		_ = load(&x, 0)
+	}()
+	_ = load(&x, 0)
+	_ = store(&x, 1)
+}
@@ -0,0 +1,22 @@
+package main
+
+import "sync"
+
+type Guard struct {
+	mu sync.Mutex
+}
+
+var count int
+
+func main() {
+	a := &Guard{}
+	b := &Guard{}
+	go func() {
+		a.mu.Lock()
+		count = 1
+		a.mu.Unlock()
+	}()
+	b.mu.Lock()
+	count = 2
+	b.mu.Unlock()
+}
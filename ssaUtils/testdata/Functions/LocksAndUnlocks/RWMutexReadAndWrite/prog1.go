@@ -0,0 +1,27 @@
+package main
+
+import "sync"
+
+type Counter struct {
+	mu    sync.RWMutex
+	count int
+}
+
+func (c *Counter) Get() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.count
+}
+
+func (c *Counter) Set(value int) {
+	c.mu.Lock()
+	c.count = value
+	c.mu.Unlock()
+}
+
+func main() {
+	c := &Counter{}
+	go c.Set(1)
+	go c.Get()
+	c.Get()
+}
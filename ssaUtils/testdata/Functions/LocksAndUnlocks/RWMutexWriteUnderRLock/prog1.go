@@ -0,0 +1,17 @@
+package main
+
+import "sync"
+
+func main() {
+	var mu sync.RWMutex
+	x := 0
+	_ = x
+	go func() {
+		mu.RLock()
+		x = 1
+		mu.RUnlock()
+	}()
+	mu.RLock()
+	x = 2
+	mu.RUnlock()
+}
@@ -0,0 +1,23 @@
+package main
+
+import "sync"
+
+type Config struct {
+	once  sync.Once
+	value int
+}
+
+func (c *Config) Get() int {
+	c.once.Do(func() {
+		c.value = 1
+	})
+	return c.value
+}
+
+func main() {
+	c := &Config{}
+	go func() {
+		_ = c.Get()
+	}()
+	_ = c.Get()
+}
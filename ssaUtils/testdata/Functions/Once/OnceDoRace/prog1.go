@@ -0,0 +1,15 @@
+package main
+
+import "sync"
+
+func main() {
+	var once sync.Once
+	x := 0
+	_ = x
+	go func() {
+		x = 2
+		once.Do(func() {})
+	}()
+	once.Do(func() {})
+	_ = x
+}
@@ -0,0 +1,17 @@
+package main
+
+import "sync"
+
+var values = make(map[string]int)
+
+var getValues = sync.OnceValue(func() map[string]int {
+	values["a"] = 1
+	return values
+})
+
+func main() {
+	go func() {
+		_ = getValues()["a"]
+	}()
+	_ = getValues()["a"]
+}
@@ -0,0 +1,22 @@
+package main
+
+type Base struct {
+	id int
+}
+
+type Derived struct {
+	Base
+	name string
+}
+
+func main() {
+	done := make(chan bool)
+	d := &Derived{}
+	go func() {
+		d.name = "a"
+		done <- true
+	}()
+	d.id = 1 // A field of the embedded struct.
+	d.Base = Base{}
+	<-done
+}
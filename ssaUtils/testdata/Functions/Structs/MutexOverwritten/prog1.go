@@ -0,0 +1,21 @@
+package main
+
+import "sync"
+
+type counter struct {
+	mu sync.Mutex
+	n  int
+}
+
+func main() {
+	c := &counter{}
+	done := make(chan bool)
+	go func() {
+		c.mu.Lock()
+		c.n++
+		c.mu.Unlock()
+		done <- true
+	}()
+	*c = counter{}
+	<-done
+}
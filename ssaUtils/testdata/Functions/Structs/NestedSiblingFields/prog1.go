@@ -0,0 +1,20 @@
+package main
+
+type inner struct {
+	c, d int
+}
+
+type outer struct {
+	b inner
+}
+
+func main() {
+	done := make(chan bool)
+	a := &outer{}
+	go func() {
+		a.b.c = 1
+		done <- true
+	}()
+	a.b.d = 2 // A sibling of the field the goroutine writes.
+	<-done
+}
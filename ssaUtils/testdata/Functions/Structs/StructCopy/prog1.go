@@ -0,0 +1,21 @@
+package main
+
+type inner struct {
+	c, d int
+}
+
+type outer struct {
+	b inner
+	e int
+}
+
+func main() {
+	done := make(chan inner)
+	a := &outer{}
+	go func() {
+		done <- a.b // Copies the whole struct, including the field written below.
+	}()
+	a.b.d = 2
+	a.e = 3
+	<-done
+}
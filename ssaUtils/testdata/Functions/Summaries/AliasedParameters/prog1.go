@@ -0,0 +1,33 @@
+package main
+
+import "sync"
+
+type Account struct {
+	mu      sync.Mutex
+	balance int
+}
+
+// update returns holding the mutex of locker, unless both accounts are the same
+func update(locker, unlocker *Account) {
+	locker.mu.Lock()
+	unlocker.mu.Unlock()
+	locker.balance++
+}
+
+func main() {
+	a := &Account{}
+	b := &Account{}
+	b.mu.Lock()
+	update(a, b)
+	a.mu.Unlock()
+	a.mu.Lock()
+	update(b, a)
+	b.mu.Unlock()
+	go func() {
+		a.mu.Lock()
+		update(a, a)
+	}()
+	a.mu.Lock()
+	a.balance++
+	a.mu.Unlock()
+}
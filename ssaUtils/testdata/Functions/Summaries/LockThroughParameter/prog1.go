@@ -0,0 +1,26 @@
+package main
+
+import "sync"
+
+type Counter struct {
+	mu    sync.Mutex
+	value int
+}
+
+func inc(c *Counter) {
+	c.mu.Lock()
+	c.value++
+	c.mu.Unlock()
+}
+
+func main() {
+	a := &Counter{}
+	b := &Counter{}
+	inc(b)
+	go func() {
+		inc(a)
+	}()
+	a.mu.Lock()
+	a.value++
+	a.mu.Unlock()
+}
@@ -0,0 +1,14 @@
+package main
+
+var count int
+
+func spawn() {
+	go func() {
+		count++
+	}()
+}
+
+func main() {
+	spawn()
+	spawn()
+}
@@ -0,0 +1,17 @@
+package main
+
+import "sync"
+
+func main() {
+	var wg sync.WaitGroup
+	results := make([]int, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = i
+		}(i)
+	}
+	wg.Wait()
+	results[0] = 5
+}
@@ -0,0 +1,25 @@
+package main
+
+import "sync"
+
+type Pool struct {
+	wg    sync.WaitGroup
+	other sync.WaitGroup
+	count int
+}
+
+func (p *Pool) work() {
+	p.count = 1
+	p.wg.Done()
+}
+
+func main() {
+	p := &Pool{}
+	p.wg.Add(1)
+	p.other.Add(1)
+	go p.work()
+	p.other.Wait()
+	p.count = 2
+	p.wg.Wait()
+	p.count = 3
+}
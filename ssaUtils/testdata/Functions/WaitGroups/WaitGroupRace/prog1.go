@@ -0,0 +1,16 @@
+package main
+
+import "sync"
+
+func main() {
+	var wg sync.WaitGroup
+	x := 0
+	_ = x
+	wg.Add(1)
+	go func() {
+		x = 1
+		wg.Done()
+	}()
+	x = 2
+	wg.Wait()
+}
@@ -0,0 +1,20 @@
+package main
+
+import "sync"
+
+func main() {
+	var wg sync.WaitGroup
+	x := 0
+	wg.Add(1)
+	go func() {
+		wg.Done()
+	}()
+	wg.Wait()
+	wg.Add(1)
+	go func() {
+		x++
+		wg.Done()
+	}()
+	x++
+	wg.Wait()
+}
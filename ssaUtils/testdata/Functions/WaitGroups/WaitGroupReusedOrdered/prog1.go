@@ -0,0 +1,22 @@
+package main
+
+import "sync"
+
+func main() {
+	var wg sync.WaitGroup
+	x := 0
+	wg.Add(1)
+	go func() {
+		x++
+		wg.Done()
+	}()
+	wg.Wait()
+	x++
+	wg.Add(1)
+	go func() {
+		x++
+		wg.Done()
+	}()
+	wg.Wait()
+	x++
+}
@@ -0,0 +1,26 @@
+package counter
+
+import "sync"
+
+// Counter is safe for concurrent use.
+type Counter struct {
+	mu    sync.Mutex
+	value int
+	hits  int
+}
+
+func (c *Counter) Inc(delta int) {
+	c.mu.Lock()
+	c.value += delta
+	c.mu.Unlock()
+}
+
+func (c *Counter) Value() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.value
+}
+
+func (c *Counter) Hit() {
+	c.hits++
+}
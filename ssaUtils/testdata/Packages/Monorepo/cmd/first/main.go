@@ -0,0 +1,7 @@
+package main
+
+import "github.com/pdufour/Chronos/ssaUtils/testdata/Packages/Monorepo/counter"
+
+func main() {
+	counter.IncrementConcurrently()
+}
@@ -0,0 +1,12 @@
+package counter
+
+var count int
+
+func Increment() {
+	count++
+}
+
+func IncrementConcurrently() {
+	go Increment()
+	Increment()
+}
@@ -0,0 +1,9 @@
+package parallel
+
+type Counter struct {
+	value int
+}
+
+func (c *Counter) Inc() {
+	c.value++
+}
@@ -0,0 +1,69 @@
+package parallel
+
+import "testing"
+
+func TestParallelSubtests(t *testing.T) {
+	c := &Counter{}
+	t.Run("first", func(t *testing.T) {
+		t.Parallel()
+		c.Inc()
+	})
+	t.Run("second", func(t *testing.T) {
+		t.Parallel()
+		c.Inc()
+	})
+}
+
+func TestSequentialSubtests(t *testing.T) {
+	c := &Counter{}
+	t.Run("first", func(t *testing.T) {
+		c.Inc()
+	})
+	t.Run("second", func(t *testing.T) {
+		c.Inc()
+	})
+}
+
+func BenchmarkInc(b *testing.B) {
+	c := &Counter{}
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.Inc()
+		}
+	})
+}
+
+var Shared int
+
+func TestWriteBeforeParallel(t *testing.T) {
+	t.Run("writer", func(t *testing.T) {
+		Shared = 1
+		t.Parallel()
+	})
+	_ = Shared
+}
+
+func TestReadAfterParallel(t *testing.T) {
+	t.Run("writer", func(t *testing.T) {
+		t.Parallel()
+		Shared = 1
+	})
+	_ = Shared
+}
+
+func TestParallelGroup(t *testing.T) {
+	c := &Counter{}
+	t.Run("group", func(t *testing.T) {
+		t.Run("first", func(t *testing.T) {
+			t.Parallel()
+			c.Inc()
+		})
+		t.Run("second", func(t *testing.T) {
+			t.Parallel()
+			c.Inc()
+		})
+	})
+	c.Inc()
+}
+
+func helper(t *testing.T) {}
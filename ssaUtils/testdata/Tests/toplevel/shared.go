@@ -0,0 +1,3 @@
+package toplevel
+
+var Shared int
@@ -0,0 +1,18 @@
+package toplevel
+
+import "testing"
+
+func TestFirstWriter(t *testing.T) {
+	t.Parallel()
+	Shared = 1
+}
+
+func TestSequentialWriter(t *testing.T) {
+	Shared = 2
+}
+
+func TestSecondWriter(t *testing.T) {
+	Shared = 3
+	t.Parallel()
+	Shared = 4
+}
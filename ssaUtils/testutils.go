@@ -2,6 +2,7 @@ package ssaUtils
 
 import (
 	"go/constant"
+	"os"
 	"path/filepath"
 	"runtime"
 	"testing"
@@ -62,6 +63,20 @@ func FindMultipleGAWithFail(t *testing.T, GuardedAccesses []*domain.GuardedAcces
 	return res
 }
 
+// GetConflictLines returns the lines of the two accesses of each conflict, the lower line first, so the conflicts can
+// be compared with the expected pairs of lines regardless of the order of their accesses.
+func GetConflictLines(prog *ssa.Program, conflicts [][]*domain.GuardedAccess) [][2]int {
+	lines := make([][2]int, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		lineA, lineB := prog.Fset.Position(conflict[0].Pos).Line, prog.Fset.Position(conflict[1].Pos).Line
+		if lineA > lineB {
+			lineA, lineB = lineB, lineA
+		}
+		lines = append(lines, [2]int{lineA, lineB})
+	}
+	return lines
+}
+
 func LoadMain(t *testing.T, filePath string) (*ssa.Function, *ssa.Package) {
 	domain.GoroutineCounter = utils.NewCounter()
 	domain.GuardedAccessCounter = utils.NewCounter()
@@ -74,11 +89,56 @@ func LoadMain(t *testing.T, filePath string) (*ssa.Function, *ssa.Package) {
 	ssaProg, ssaPkg, err := LoadPackage(filePath, modulePath)
 	require.NoError(t, err)
 	f := ssaPkg.Func("main")
-	err = InitPreProcess(ssaProg, modulePath)
+	InitPreProcess(ssaProg, LoadTestScope(t, modulePath))
+	return f, ssaPkg
+}
+
+// LoadMainFromSource loads the main function of a program generated by the test, like LoadMain. The source is written
+// to a module of its own in a temporary directory, which is the scope of the analysis.
+func LoadMainFromSource(t *testing.T, source string) (*ssa.Function, *ssa.Package) {
+	domain.GoroutineCounter = utils.NewCounter()
+	domain.GuardedAccessCounter = utils.NewCounter()
+	domain.PosIDCounter = utils.NewCounter()
+
+	modulePath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(modulePath, "go.mod"), []byte("module generated\n\ngo 1.18\n"), 0o644))
+	filePath := filepath.Join(modulePath, "prog1.go")
+	require.NoError(t, os.WriteFile(filePath, []byte(source), 0o644))
+
+	ssaProg, ssaPkg, modules, err := LoadPackageWithModules(filePath, modulePath)
+	require.NoError(t, err)
+	f := ssaPkg.Func("main")
+	scope, err := NewScope(modules, nil, nil)
 	require.NoError(t, err)
+	InitPreProcess(ssaProg, scope)
 	return f, ssaPkg
 }
 
+// LoadMainWithDispatch loads the main function of the file like LoadMain, choosing the implementations called by
+// interface method calls with the dispatch algorithm.
+func LoadMainWithDispatch(t *testing.T, filePath string, dispatch Dispatch) (*ssa.Function, *ssa.Package) {
+	GlobalDispatch = dispatch
+	t.Cleanup(func() {
+		GlobalDispatch = DispatchTypes
+	})
+	return LoadMain(t, filePath)
+}
+
+var testScope *Scope
+
+// LoadTestScope returns the scope of the module in modulePath. All the tests share the same module, so it's only
+// loaded once.
+func LoadTestScope(t *testing.T, modulePath string) *Scope {
+	if testScope != nil {
+		return testScope
+	}
+	modules, err := GetMainModules(modulePath, "./domain")
+	require.NoError(t, err)
+	testScope, err = NewScope(modules, nil, nil)
+	require.NoError(t, err)
+	return testScope
+}
+
 func EqualDifferentOrder(a, b []*domain.GuardedAccess) bool {
 	if len(a) != len(b) {
 		return false
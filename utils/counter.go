@@ -11,4 +11,9 @@ func NewCounter() *Counter {
 func (c *Counter) GetNext() int {
 	c.count += 1
 	return c.count
-}
\ No newline at end of file
+}
+
+// Current returns the last value returned by GetNext.
+func (c *Counter) Current() int {
+	return c.count
+}